@@ -0,0 +1,455 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+	"rischmann.fr/cassandra-partition-calculator/cql"
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/lint"
+)
+
+//
+// Request and response bodies of the JSON API
+//
+
+type apiEstimateRequest struct {
+	Schema        string             `json:"schema"`
+	Rows          *int64             `json:"rows"`
+	SizeEstimates map[string]int     `json:"size_estimates,omitempty"`
+	Options       apiEstimateOptions `json:"options"`
+}
+
+type apiEstimateOptions struct {
+	// Table is the name of the table to estimate if the schema contains more than one
+	Table string `json:"table,omitempty"`
+	// Lint runs the lint rules on the table
+	Lint bool `json:"lint,omitempty"`
+	// Units adds the sizes formatted with these units to the estimation, see format.ParseUnits
+	Units string `json:"units,omitempty"`
+}
+
+type apiColumn struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Static    bool   `json:"static"`
+	FixedSize bool   `json:"fixed_size"`
+	Size      int    `json:"size"`
+}
+
+type apiSchema struct {
+	Keyspace      string      `json:"keyspace,omitempty"`
+	Table         string      `json:"table"`
+	Columns       []apiColumn `json:"columns"`
+	PartitionKey  []string    `json:"partition_key"`
+	ClusteringKey []string    `json:"clustering_key"`
+}
+
+type apiBreakdown struct {
+	PartitionKeyBytes  int `json:"partition_key_bytes"`
+	ClusteringKeyBytes int `json:"clustering_key_bytes"`
+	MetadataBytes      int `json:"metadata_bytes"`
+	RowsBytes          int `json:"rows_bytes"`
+}
+
+type apiEstimation struct {
+	Rows      int64                   `json:"rows"`
+	Values    int                     `json:"values"`
+	Bytes     int                     `json:"bytes"`
+	Breakdown apiBreakdown            `json:"breakdown"`
+	Formatted *apiFormattedEstimation `json:"formatted,omitempty"`
+}
+
+// apiFormattedEstimation is the estimation formatted for humans in the language of the request.
+type apiFormattedEstimation struct {
+	Units              string `json:"units"`
+	Values             string `json:"values"`
+	Bytes              string `json:"bytes"`
+	PartitionKeyBytes  string `json:"partition_key_bytes"`
+	ClusteringKeyBytes string `json:"clustering_key_bytes"`
+	MetadataBytes      string `json:"metadata_bytes"`
+	RowsBytes          string `json:"rows_bytes"`
+}
+
+type apiFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Column   string `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+type apiEstimateResponse struct {
+	Schema     apiSchema     `json:"schema"`
+	Estimation apiEstimation `json:"estimation"`
+	Findings   []apiFinding  `json:"findings,omitempty"`
+}
+
+type apiPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type apiError struct {
+	Code     string       `json:"code"`
+	Field    string       `json:"field,omitempty"`
+	Message  string       `json:"message"`
+	Position *apiPosition `json:"position,omitempty"`
+}
+
+type apiErrorResponse struct {
+	Errors []apiError `json:"errors"`
+}
+
+const (
+	apiErrorInvalidJSON          = "invalid_json"
+	apiErrorInvalidField         = "invalid_field"
+	apiErrorInvalidSchema        = "invalid_schema"
+	apiErrorEstimationFailed     = "estimation_failed"
+	apiErrorMethodNotAllowed     = "method_not_allowed"
+	apiErrorUnsupportedMediaType = "unsupported_media_type"
+	apiErrorNotAcceptable        = "not_acceptable"
+	apiErrorNotFound             = "not_found"
+	apiErrorInternal             = "internal_error"
+	apiErrorRequestTooLarge      = "request_too_large"
+)
+
+func newAPISchema(schema cql.Schema) apiSchema {
+	columnNames := func(columns cql.ColumnDefinitions) []string {
+		res := make([]string, 0, len(columns))
+		for _, column := range columns {
+			res = append(res, column.Name)
+		}
+		return res
+	}
+
+	res := apiSchema{
+		Keyspace:      schema.Keyspace,
+		Table:         schema.TableName,
+		Columns:       make([]apiColumn, 0, len(schema.Columns)),
+		PartitionKey:  columnNames(schema.PrimaryKey.PartitionKey.Columns),
+		ClusteringKey: columnNames(schema.PrimaryKey.ClusteringKey.Columns),
+	}
+	for _, column := range schema.Columns {
+		res.Columns = append(res.Columns, apiColumn{
+			Name:      column.Name,
+			Type:      column.Type.Name,
+			Static:    column.Static,
+			FixedSize: column.Type.IsFixedSize(),
+			Size:      column.Size(),
+		})
+	}
+
+	return res
+}
+
+func newAPIEstimation(rows int64, estimation cassandra.Estimation) apiEstimation {
+	return apiEstimation{
+		Rows:   rows,
+		Values: estimation.Values,
+		Bytes:  estimation.Bytes,
+		Breakdown: apiBreakdown{
+			PartitionKeyBytes:  estimation.PartitionKeyBytes,
+			ClusteringKeyBytes: estimation.ClusteringKeyBytes,
+			MetadataBytes:      estimation.MetadataBytes,
+			RowsBytes:          estimation.RowsBytes,
+		},
+	}
+}
+
+func newAPIFormattedEstimation(formatter format.Formatter, estimation cassandra.Estimation) *apiFormattedEstimation {
+	return &apiFormattedEstimation{
+		Units:              formatter.Units().String(),
+		Values:             formatter.Number(int64(estimation.Values)),
+		Bytes:              formatter.Bytes(int64(estimation.Bytes)),
+		PartitionKeyBytes:  formatter.Bytes(int64(estimation.PartitionKeyBytes)),
+		ClusteringKeyBytes: formatter.Bytes(int64(estimation.ClusteringKeyBytes)),
+		MetadataBytes:      formatter.Bytes(int64(estimation.MetadataBytes)),
+		RowsBytes:          formatter.Bytes(int64(estimation.RowsBytes)),
+	}
+}
+
+func newAPIFindings(findings []lint.Finding) []apiFinding {
+	res := make([]apiFinding, 0, len(findings))
+	for _, finding := range findings {
+		res = append(res, apiFinding{
+			Rule:     finding.Rule,
+			Severity: string(finding.Severity),
+			Column:   finding.Column,
+			Message:  finding.Message,
+		})
+	}
+	return res
+}
+
+// newAPIErrors converts err to a list of API errors.
+// Schema violations and syntax errors are returned with their position.
+func newAPIErrors(err error) []apiError {
+	var violations cql.Violations
+	if errors.As(err, &violations) {
+		res := make([]apiError, 0, len(violations))
+		for _, violation := range violations {
+			res = append(res, apiError{
+				Code:    apiErrorInvalidSchema,
+				Field:   "schema",
+				Message: violation.Message,
+				Position: &apiPosition{
+					Line:   violation.Pos.Line,
+					Column: violation.Pos.Column,
+				},
+			})
+		}
+		return res
+	}
+
+	var (
+		vErr      *validationError
+		syntaxErr *cql.SyntaxError
+	)
+	if errors.As(err, &vErr) && errors.As(err, &syntaxErr) {
+		return []apiError{{
+			Code:    apiErrorInvalidSchema,
+			Field:   vErr.field,
+			Message: syntaxErr.Err.Error(),
+			Position: &apiPosition{
+				Line:   syntaxErr.Pos.Line,
+				Column: syntaxErr.Pos.Column,
+			},
+		}}
+	}
+
+	if errors.As(err, &vErr) {
+		return []apiError{{
+			Code:    apiErrorInvalidField,
+			Field:   vErr.field,
+			Message: vErr.err.Error(),
+		}}
+	}
+
+	return []apiError{{
+		Code:    apiErrorEstimationFailed,
+		Message: err.Error(),
+	}}
+}
+
+//
+// Handlers
+//
+
+// openAPIDocument is the OpenAPI 3 specification of the JSON API.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+func openAPIHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeAPIErrors(w, http.StatusMethodNotAllowed, apiError{
+			Code:    apiErrorMethodNotAllowed,
+			Message: fmt.Sprintf("method %s is not allowed", req.Method),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
+
+func writeAPIErrors(w http.ResponseWriter, status int, errors ...apiError) {
+	writeJSON(w, status, apiErrorResponse{Errors: errors})
+}
+
+// acceptsJSON returns true if the Accept header allows a JSON response.
+func acceptsJSON(req *http.Request) bool {
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return true
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		switch mediaType {
+		case "application/json", "application/*", "*/*":
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowAPIMethods returns true if the request method is one of methods, otherwise it replies with a 405.
+func allowAPIMethods(w http.ResponseWriter, req *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if req.Method == method {
+			return true
+		}
+	}
+
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeAPIErrors(w, http.StatusMethodNotAllowed, apiError{
+		Code:    apiErrorMethodNotAllowed,
+		Message: fmt.Sprintf("method %s is not allowed", req.Method),
+	})
+
+	return false
+}
+
+// requireAcceptsJSON returns true if the client accepts a JSON response, otherwise it replies with a 406.
+func requireAcceptsJSON(w http.ResponseWriter, req *http.Request) bool {
+	if acceptsJSON(req) {
+		return true
+	}
+
+	writeAPIErrors(w, http.StatusNotAcceptable, apiError{
+		Code:    apiErrorNotAcceptable,
+		Message: "only application/json responses are available",
+	})
+
+	return false
+}
+
+// decodeAPIRequest decodes the JSON request body into body.
+// It returns false after replying with the appropriate error if that's not possible.
+func decodeAPIRequest(w http.ResponseWriter, req *http.Request, body interface{}) bool {
+	if !requireAcceptsJSON(w, req) {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		writeAPIErrors(w, http.StatusUnsupportedMediaType, apiError{
+			Code:    apiErrorUnsupportedMediaType,
+			Message: "the request body must be application/json",
+		})
+		return false
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(body); err != nil {
+		if isBodyTooLarge(err) {
+			writeAPIErrors(w, http.StatusRequestEntityTooLarge, apiError{
+				Code:    apiErrorRequestTooLarge,
+				Message: err.Error(),
+			})
+			return false
+		}
+
+		writeAPIErrors(w, http.StatusBadRequest, apiError{
+			Code:    apiErrorInvalidJSON,
+			Message: err.Error(),
+		})
+		return false
+	}
+
+	return true
+}
+
+func (c *serveCommandConfig) apiEstimateHandler(w http.ResponseWriter, req *http.Request) {
+	// Parse the request
+
+	if !allowAPIMethods(w, req, http.MethodPost) {
+		return
+	}
+
+	var body apiEstimateRequest
+	if !decodeAPIRequest(w, req, &body) {
+		return
+	}
+
+	res, err := c.parseAPIEstimateRequest(body)
+	if err != nil {
+		c.logger(req.Context()).Debug("unable to parse estimate request", zap.Error(err))
+
+		writeAPIErrors(w, http.StatusUnprocessableEntity, newAPIErrors(err)...)
+		return
+	}
+
+	// Get an estimation
+
+	estimation, err := c.metrics.estimate(res.schema, res.rows)
+	if err != nil {
+		c.logger(req.Context()).Error("unable to estimate", zap.Error(err))
+
+		writeAPIErrors(w, http.StatusInternalServerError, newAPIErrors(err)...)
+		return
+	}
+
+	response := apiEstimateResponse{
+		Schema:     newAPISchema(res.schema),
+		Estimation: newAPIEstimation(res.rows, estimation),
+	}
+	if body.Options.Units != "" {
+		response.Estimation.Formatted = newAPIFormattedEstimation(newFormatter(req.Context(), res.units), estimation)
+	}
+	if body.Options.Lint {
+		response.Findings = newAPIFindings(lint.Run(c.lintConfig, res.schema))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (c *serveCommandConfig) parseAPIEstimateRequest(body apiEstimateRequest) (res evaluationSchema, err error) {
+	if body.Rows == nil {
+		return res, &validationError{
+			field: "rows",
+			err:   errFieldEmpty,
+		}
+	}
+	if *body.Rows < 0 {
+		return res, &validationError{
+			field: "rows",
+			err:   errNegativeValue,
+		}
+	}
+	res.rows = *body.Rows
+
+	res.units, err = format.ParseUnits(body.Options.Units)
+	if err != nil {
+		return res, &validationError{
+			field: "options.units",
+			err:   err,
+		}
+	}
+
+	if body.Schema == "" {
+		return res, &validationError{
+			field: "schema",
+			err:   errFieldEmpty,
+		}
+	}
+
+	res.schema, err = c.parseSchemaField("schema", body.Schema, body.Options.Table)
+	if err != nil {
+		return res, err
+	}
+
+	for columnName, size := range body.SizeEstimates {
+		res.schema, err = withColumnSizeEstimate(res.schema, "size_estimates."+columnName, columnName, size)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// openAPISpec is the subset of an OpenAPI document needed to validate requests and responses.
+type openAPISpec struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Responses map[string]openAPIResponse `json:"responses"`
+		Schemas   map[string]*jsonSchema     `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema *jsonSchema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIResponse struct {
+	Ref     string `json:"$ref"`
+	Content map[string]struct {
+		Schema *jsonSchema `json:"schema"`
+	} `json:"content"`
+}
+
+// jsonSchema is the subset of JSON schema used by openapi.json.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []string               `json:"enum"`
+	MinItems             *int                   `json:"minItems"`
+	Minimum              *float64               `json:"minimum"`
+}
+
+func loadOpenAPISpec(t testing.TB) *openAPISpec {
+	var spec openAPISpec
+	require.NoError(t, json.Unmarshal(openAPIDocument, &spec))
+	require.True(t, strings.HasPrefix(spec.OpenAPI, "3."))
+
+	return &spec
+}
+
+func (s *openAPISpec) resolve(t testing.TB, schema *jsonSchema) *jsonSchema {
+	for schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		resolved, ok := s.Components.Schemas[name]
+		require.True(t, ok, "unknown schema reference %q", schema.Ref)
+		schema = resolved
+	}
+	return schema
+}
+
+// validate returns the list of places where value doesn't match schema.
+func (s *openAPISpec) validate(t testing.TB, path string, schema *jsonSchema, value interface{}) (errors []string) {
+	schema = s.resolve(t, schema)
+
+	fail := func(format string, args ...interface{}) {
+		errors = append(errors, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expected an object, got %T", value)
+			return
+		}
+
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+
+		var additional *jsonSchema
+		allowAdditional := true
+		if len(schema.AdditionalProperties) > 0 {
+			if err := json.Unmarshal(schema.AdditionalProperties, &allowAdditional); err != nil {
+				additional = new(jsonSchema)
+				require.NoError(t, json.Unmarshal(schema.AdditionalProperties, additional))
+			}
+		}
+
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := schema.Properties[name]
+			switch {
+			case ok:
+				errors = append(errors, s.validate(t, path+"."+name, property, object[name])...)
+			case additional != nil:
+				errors = append(errors, s.validate(t, path+"."+name, additional, object[name])...)
+			case !allowAdditional:
+				fail("unexpected property %q", name)
+			}
+		}
+
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			fail("expected an array, got %T", value)
+			return
+		}
+		if schema.MinItems != nil && len(array) < *schema.MinItems {
+			fail("expected at least %d items, got %d", *schema.MinItems, len(array))
+		}
+		for i, item := range array {
+			errors = append(errors, s.validate(t, path+"["+strconv.Itoa(i)+"]", schema.Items, item)...)
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("expected a string, got %T", value)
+			return
+		}
+		if len(schema.Enum) > 0 {
+			found := false
+			for _, elem := range schema.Enum {
+				found = found || elem == str
+			}
+			if !found {
+				fail("value %q is not one of %v", str, schema.Enum)
+			}
+		}
+
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok {
+			fail("expected a number, got %T", value)
+			return
+		}
+		if schema.Type == "integer" && number != float64(int64(number)) {
+			fail("expected an integer, got %v", number)
+		}
+		if schema.Minimum != nil && number < *schema.Minimum {
+			fail("expected at least %v, got %v", *schema.Minimum, number)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected a boolean, got %T", value)
+		}
+	}
+
+	return errors
+}
+
+// responseSchema returns the schema of the response of the operation for the status code.
+func (s *openAPISpec) responseSchema(t testing.TB, path, method string, status int) *jsonSchema {
+	operation, ok := s.Paths[path][strings.ToLower(method)]
+	require.True(t, ok, "operation %s %s is not documented", method, path)
+
+	response, ok := operation.Responses[strconv.Itoa(status)]
+	require.True(t, ok, "status %d of %s %s is not documented", status, method, path)
+
+	if ref := response.Ref; ref != "" {
+		response, ok = s.Components.Responses[strings.TrimPrefix(ref, "#/components/responses/")]
+		require.True(t, ok, "unknown response reference %q", ref)
+	}
+
+	content, ok := response.Content["application/json"]
+	require.True(t, ok, "status %d of %s %s has no JSON content", status, method, path)
+
+	return content.Schema
+}
+
+func requireMatchesSchema(t testing.TB, spec *openAPISpec, schema *jsonSchema, body []byte) {
+	var value interface{}
+	require.NoError(t, json.Unmarshal(body, &value), "body is not valid JSON: %s", string(body))
+
+	errors := spec.validate(t, "$", schema, value)
+	require.Empty(t, errors, "body doesn't match the specification: %s", string(body))
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	openAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.Equal(t, openAPIDocument, rec.Body.Bytes())
+
+	loadOpenAPISpec(t)
+}
+
+func TestAPIEstimateContract(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	c := newTestServeCommandConfig(t)
+
+	requestSchema := spec.Paths["/api/v1/estimate"]["post"].RequestBody.Content["application/json"].Schema
+
+	testCases := []struct {
+		method      string
+		contentType string
+		accept      string
+		body        string
+		valid       bool
+	}{
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE ks.events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));", "rows": 1000, "size_estimates": {"event_data": 100}}`,
+			valid:       true,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE flags(enabled boolean PRIMARY KEY, tags set<text>);", "rows": 10, "options": {"lint": true, "table": "flags"}}`,
+			valid:       true,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE flags(enabled boolean PRIMARY KEY, tags set<text>);", "rows": 10, "options": {"units": "si"}}`,
+			valid:       true,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE flags(enabled boolean PRIMARY KEY);", "rows": 10, "options": {"units": "furlong"}}`,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE events(user_id uuid PRIMARY KEY, name text STATIC);", "rows": 10}`,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE events(user_id uuid PRIMARY KEY);", "rows": 10, "size_estimates": {"foo": 10}}`,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE", "rows": 10}`,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": `,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "text/plain",
+			body:        `{}`,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			accept:      "text/html",
+			body:        `{}`,
+		},
+		{
+			method: http.MethodDelete,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			// Check the requests that must succeed against the specification too
+			if tc.valid {
+				var requestBody interface{}
+				require.NoError(t, json.Unmarshal([]byte(tc.body), &requestBody))
+
+				errors := spec.validate(t, "$", requestSchema, requestBody)
+				require.Empty(t, errors, "request doesn't match the specification")
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/v1/estimate", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			rec := httptest.NewRecorder()
+			c.apiEstimateHandler(rec, req)
+
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			if tc.valid {
+				require.Equal(t, http.StatusOK, rec.Code)
+			}
+
+			// Errors for other methods are documented on the POST operation
+			schema := spec.responseSchema(t, "/api/v1/estimate", http.MethodPost, rec.Code)
+			requireMatchesSchema(t, spec, schema, rec.Body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/scenario"
+)
+
+//
+// Request and response bodies of the scenarios API
+//
+
+type apiScenarioRequest struct {
+	Name          string         `json:"name"`
+	Notes         string         `json:"notes,omitempty"`
+	Schema        string         `json:"schema"`
+	Rows          *int64         `json:"rows"`
+	SizeEstimates map[string]int `json:"size_estimates,omitempty"`
+	// TableRows are the numbers of rows of the tables of a keyspace, Rows is the default
+	TableRows map[string]int64 `json:"table_rows,omitempty"`
+	// Units are the units the sizes are displayed with in the web UI, see format.ParseUnits
+	Units string `json:"units,omitempty"`
+}
+
+type apiCloneScenarioRequest struct {
+	// Name is the name of the clone, it defaults to "Copy of <name>"
+	Name string `json:"name,omitempty"`
+}
+
+type apiScenario struct {
+	ID            uint64           `json:"id"`
+	Name          string           `json:"name"`
+	Notes         string           `json:"notes,omitempty"`
+	Schema        string           `json:"schema"`
+	Rows          int64            `json:"rows"`
+	SizeEstimates map[string]int   `json:"size_estimates,omitempty"`
+	TableRows     map[string]int64 `json:"table_rows,omitempty"`
+	Units         string           `json:"units,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+type apiScenariosResponse struct {
+	Scenarios []apiScenario `json:"scenarios"`
+}
+
+func newAPIScenario(s scenario.Scenario) apiScenario {
+	return apiScenario{
+		ID:            s.ID,
+		Name:          s.Name,
+		Notes:         s.Notes,
+		Schema:        s.Schema,
+		Rows:          s.Rows,
+		SizeEstimates: s.SizeEstimates,
+		TableRows:     s.TableRows,
+		Units:         s.Units,
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+	}
+}
+
+//
+// Handlers
+//
+
+func (c *serveCommandConfig) writeScenarioStoreError(w http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, scenario.ErrNotFound) {
+		writeAPIErrors(w, http.StatusNotFound, apiError{
+			Code:    apiErrorNotFound,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.logger(req.Context()).Error("unable to access scenarios", zap.Error(err))
+
+	writeAPIErrors(w, http.StatusInternalServerError, apiError{
+		Code:    apiErrorInternal,
+		Message: "unable to access the scenarios",
+	})
+}
+
+// requireScenarios returns true if saved scenarios are enabled, otherwise it replies with a 404.
+func (c *serveCommandConfig) requireScenarios(w http.ResponseWriter) bool {
+	if c.scenarios != nil {
+		return true
+	}
+
+	writeAPIErrors(w, http.StatusNotFound, apiError{
+		Code:    apiErrorNotFound,
+		Message: "saved scenarios are disabled",
+	})
+
+	return false
+}
+
+// apiScenariosHandler serves the collection of scenarios:
+// * GET /api/v1/scenarios lists the scenarios
+// * POST /api/v1/scenarios creates a scenario
+func (c *serveCommandConfig) apiScenariosHandler(w http.ResponseWriter, req *http.Request) {
+	if !c.requireScenarios(w) || !allowAPIMethods(w, req, http.MethodGet, http.MethodPost) {
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		if !requireAcceptsJSON(w, req) {
+			return
+		}
+
+		scenarios, err := c.scenarios.List()
+		if err != nil {
+			c.writeScenarioStoreError(w, req, err)
+			return
+		}
+
+		response := apiScenariosResponse{
+			Scenarios: make([]apiScenario, 0, len(scenarios)),
+		}
+		for _, s := range scenarios {
+			response.Scenarios = append(response.Scenarios, newAPIScenario(s))
+		}
+
+		writeJSON(w, http.StatusOK, response)
+
+	case http.MethodPost:
+		c.apiSaveScenario(w, req, 0)
+	}
+}
+
+// apiScenarioHandler serves a single scenario:
+// * GET /api/v1/scenarios/<id> returns the scenario
+// * PUT /api/v1/scenarios/<id> replaces the scenario
+// * DELETE /api/v1/scenarios/<id> deletes the scenario
+// * POST /api/v1/scenarios/<id>/clone clones the scenario
+func (c *serveCommandConfig) apiScenarioHandler(w http.ResponseWriter, req *http.Request) {
+	if !c.requireScenarios(w) {
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/api/v1/scenarios/")
+	idStr, action, _ := strings.Cut(path, "/")
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || (action != "" && action != "clone") {
+		writeAPIErrors(w, http.StatusNotFound, apiError{
+			Code:    apiErrorNotFound,
+			Message: "no such resource",
+		})
+		return
+	}
+
+	if action == "clone" {
+		if !allowAPIMethods(w, req, http.MethodPost) {
+			return
+		}
+
+		var body apiCloneScenarioRequest
+		if !decodeAPIRequest(w, req, &body) {
+			return
+		}
+
+		clone, err := c.scenarios.Clone(id, strings.TrimSpace(body.Name))
+		if err != nil {
+			c.writeScenarioStoreError(w, req, err)
+			return
+		}
+
+		w.Header().Set("Location", c.baseURLOf(req.Context())+"/api/v1/scenarios/"+strconv.FormatUint(clone.ID, 10))
+		writeJSON(w, http.StatusCreated, newAPIScenario(clone))
+		return
+	}
+
+	if !allowAPIMethods(w, req, http.MethodGet, http.MethodPut, http.MethodDelete) {
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		if !requireAcceptsJSON(w, req) {
+			return
+		}
+
+		s, err := c.scenarios.Get(id)
+		if err != nil {
+			c.writeScenarioStoreError(w, req, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIScenario(s))
+
+	case http.MethodPut:
+		c.apiSaveScenario(w, req, id)
+
+	case http.MethodDelete:
+		if err := c.scenarios.Delete(id); err != nil {
+			c.writeScenarioStoreError(w, req, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// apiSaveScenario creates the scenario in the request body if id is zero, otherwise it replaces the scenario identified by id.
+func (c *serveCommandConfig) apiSaveScenario(w http.ResponseWriter, req *http.Request, id uint64) {
+	var body apiScenarioRequest
+	if !decodeAPIRequest(w, req, &body) {
+		return
+	}
+
+	// Only valid calculations can be saved
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		writeAPIErrors(w, http.StatusUnprocessableEntity, newAPIErrors(&validationError{
+			field: "name",
+			err:   errFieldEmpty,
+		})...)
+		return
+	}
+
+	// The size estimates of a keyspace are prefixed with their table, they are validated like in the web form
+	keyspace := c.isKeyspaceForm(url.Values{"schema": {body.Schema}})
+
+	estimateRequest := apiEstimateRequest{
+		Schema: body.Schema,
+		Rows:   body.Rows,
+	}
+	if !keyspace {
+		estimateRequest.SizeEstimates = body.SizeEstimates
+	}
+
+	res, err := c.parseAPIEstimateRequest(estimateRequest)
+	if err == nil {
+		err = validateAPITableRows(body.TableRows)
+	}
+	if _, unitsErr := format.ParseUnits(body.Units); err == nil && unitsErr != nil {
+		err = &validationError{
+			field: "units",
+			err:   unitsErr,
+		}
+	}
+
+	s := scenario.Scenario{
+		ID:            id,
+		Name:          name,
+		Notes:         body.Notes,
+		Schema:        body.Schema,
+		Rows:          res.rows,
+		SizeEstimates: body.SizeEstimates,
+		TableRows:     body.TableRows,
+		Units:         body.Units,
+	}
+	if err == nil && keyspace {
+		_, err = c.parseKeyspaceForm(scenarioForm(s))
+	}
+
+	if err != nil {
+		c.logger(req.Context()).Error("unable to parse scenario", zap.Error(err))
+
+		writeAPIErrors(w, http.StatusUnprocessableEntity, newAPIErrors(err)...)
+		return
+	}
+
+	saved, err := c.scenarios.Save(s)
+	if err != nil {
+		c.writeScenarioStoreError(w, req, err)
+		return
+	}
+
+	if id != 0 {
+		writeJSON(w, http.StatusOK, newAPIScenario(saved))
+		return
+	}
+
+	w.Header().Set("Location", c.baseURLOf(req.Context())+"/api/v1/scenarios/"+strconv.FormatUint(saved.ID, 10))
+	writeJSON(w, http.StatusCreated, newAPIScenario(saved))
+}
+
+// validateAPITableRows returns an error if a number of rows of a table is negative.
+func validateAPITableRows(tableRows map[string]int64) error {
+	tables := make([]string, 0, len(tableRows))
+	for table := range tableRows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		if tableRows[table] < 0 {
+			return &validationError{
+				field: "table_rows." + table,
+				err:   errNegativeValue,
+			}
+		}
+	}
+
+	return nil
+}
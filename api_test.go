@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"rischmann.fr/cassandra-partition-calculator/lint"
+)
+
+func newTestServeCommandConfig(t testing.TB) *serveCommandConfig {
+	c := &serveCommandConfig{
+		root: &rootCommandConfig{
+			logger: zap.NewNop(),
+		},
+		listenAddr:  ":0",
+		lintConfig:  lint.DefaultConfig(),
+		schemaCache: newSchemaCache(16),
+		metrics:     newServerMetrics(),
+		limits:      defaultServerLimits(),
+	}
+	require.NoError(t, c.loadExamples())
+
+	return c
+}
+
+func doAPIEstimate(t testing.TB, c *serveCommandConfig, method string, contentType string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v1/estimate", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	rec := httptest.NewRecorder()
+	c.apiEstimateHandler(rec, req)
+
+	return rec
+}
+
+func TestAPIEstimate(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	const body = `{
+		"schema": "CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));",
+		"rows": 1000,
+		"size_estimates": {"event_data": 100},
+		"options": {"lint": true}
+	}`
+
+	rec := doAPIEstimate(t, c, http.MethodPost, "application/json", body)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var response apiEstimateResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	require.Equal(t, "events", response.Schema.Table)
+	require.Equal(t, []string{"user_id"}, response.Schema.PartitionKey)
+	require.Equal(t, []string{"event_id"}, response.Schema.ClusteringKey)
+	require.Equal(t, 100, response.Schema.Columns[2].Size)
+
+	require.Equal(t, int64(1000), response.Estimation.Rows)
+	require.Equal(t, 1000, response.Estimation.Values)
+
+	breakdown := response.Estimation.Breakdown
+	require.Equal(t, response.Estimation.Bytes, breakdown.PartitionKeyBytes+breakdown.ClusteringKeyBytes+breakdown.MetadataBytes+breakdown.RowsBytes)
+
+	require.Nil(t, response.Estimation.Formatted)
+}
+
+func TestAPIEstimateUnits(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	const body = `{
+		"schema": "CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));",
+		"rows": 10000,
+		"size_estimates": {"event_data": 100},
+		"options": {"units": "kb"}
+	}`
+
+	rec := doAPIEstimate(t, c, http.MethodPost, "application/json", body)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response apiEstimateResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	formatted := response.Estimation.Formatted
+	require.NotNil(t, formatted)
+	require.Equal(t, "kB", formatted.Units)
+	require.Equal(t, "10,000", formatted.Values)
+	require.Equal(t, "1,320.03 kB", formatted.Bytes)
+	require.Equal(t, "0.02 kB", formatted.PartitionKeyBytes)
+}
+
+func TestAPIEstimateErrors(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	testCases := []struct {
+		method      string
+		contentType string
+		body        string
+		status      int
+		exp         []apiError
+	}{
+		{
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+			exp:    []apiError{{Code: apiErrorMethodNotAllowed, Message: "method GET is not allowed"}},
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "rows=10",
+			status:      http.StatusUnsupportedMediaType,
+			exp:         []apiError{{Code: apiErrorUnsupportedMediaType, Message: "the request body must be application/json"}},
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"rows": "foo"}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE events(user_id uuid PRIMARY KEY);"}`,
+			status:      http.StatusUnprocessableEntity,
+			exp:         []apiError{{Code: apiErrorInvalidField, Field: "rows", Message: "field empty"}},
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE events(user_id uuid PRIMARY KEY);", "rows": 10, "size_estimates": {"user_id": 10}}`,
+			status:      http.StatusUnprocessableEntity,
+			exp:         []apiError{{Code: apiErrorInvalidField, Field: "size_estimates.user_id", Message: "column has a fixed size"}},
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE events(user_id uuid PRIMARY KEY);", "rows": 10, "options": {"units": "furlong"}}`,
+			status:      http.StatusUnprocessableEntity,
+			exp:         []apiError{{Code: apiErrorInvalidField, Field: "options.units", Message: `invalid units "furlong", expected iec, si or a unit like MiB`}},
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"schema": "CREATE TABLE events(\n  user_id uuid PRIMARY KEY,\n  name text STATIC\n);", "rows": 10}`,
+			status:      http.StatusUnprocessableEntity,
+			exp: []apiError{{
+				Code:     apiErrorInvalidSchema,
+				Field:    "schema",
+				Message:  `static column "name" is not allowed on a table without clustering columns`,
+				Position: &apiPosition{Line: 3, Column: 3},
+			}},
+		},
+		{
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"schema": "CREATE TABLE events(\n  user_id uuid,\n  PRIMARY KEY (id)\n);", "rows": 10}`,
+			status:      http.StatusUnprocessableEntity,
+			exp: []apiError{{
+				Code:     apiErrorInvalidSchema,
+				Field:    "schema",
+				Message:  `invalid column "id" in primary key`,
+				Position: &apiPosition{Line: 3, Column: 16},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			rec := doAPIEstimate(t, c, tc.method, tc.contentType, tc.body)
+			require.Equal(t, tc.status, rec.Code)
+
+			var response apiErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			require.NotEmpty(t, response.Errors)
+
+			if tc.exp != nil {
+				require.Equal(t, tc.exp, response.Errors)
+			}
+		})
+	}
+}
+
+func TestAPIEstimateNotAcceptable(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/estimate", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/html")
+
+	rec := httptest.NewRecorder()
+	c.apiEstimateHandler(rec, req)
+
+	require.Equal(t, http.StatusNotAcceptable, rec.Code)
+}
@@ -10,8 +10,8 @@ import (
 var Mode = "dev"
 
 func init() {
-	fs := os.DirFS("assets")
-	server := http.FileServer(http.FS(fs))
+	FS = os.DirFS("assets")
+	server := http.FileServer(http.FS(FS))
 
 	FileServer = http.StripPrefix("/assets", server)
 }
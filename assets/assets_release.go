@@ -10,9 +10,10 @@ import (
 var Mode = "release"
 
 //go:embed *.css *.js
-var fs embed.FS
+var embedded embed.FS
 
 func init() {
-	server := http.FileServer(http.FS(fs))
+	FS = embedded
+	server := http.FileServer(http.FS(FS))
 	FileServer = http.StripPrefix("/assets", server)
 }
@@ -0,0 +1,159 @@
+// Schema editor
+//
+// Decorates the schema textarea with a gutter of line numbers and highlights the errors
+// located in the schema, as rendered by the server in #error-messages.
+//
+// The highlights are drawn in a backdrop mirroring the textarea content: the textarea is
+// transparent and the backdrop, behind it, wraps the offending ranges in <mark> elements.
+
+(function () {
+  "use strict";
+
+  function escapeHTML(text) {
+    return text.replace(/[&<>"']/g, (ch) => `&#${ch.charCodeAt(0)};`);
+  }
+
+  // offset converts a 1-based line and column, counted in code points like the server does,
+  // to an offset in the textarea value.
+  function offset(lines, line, column) {
+    let res = 0;
+    for (let i = 0; i < line - 1 && i < lines.length; i++) {
+      res += lines[i].length + 1;
+    }
+
+    const current = lines[line - 1] || "";
+    return res + Array.from(current).slice(0, column - 1).join("").length;
+  }
+
+  function schemaErrors() {
+    return Array.from(document.querySelectorAll("#error-messages .schema-error")).map((elt) => ({
+      message: elt.dataset.message,
+      line: parseInt(elt.dataset.line, 10),
+      column: parseInt(elt.dataset.column, 10),
+      endLine: parseInt(elt.dataset.endLine, 10),
+      endColumn: parseInt(elt.dataset.endColumn, 10),
+    }));
+  }
+
+  // ranges returns the ranges to highlight in text, sorted and without overlaps.
+  function ranges(text, errors) {
+    const lines = text.split("\n");
+
+    const res = errors
+      .filter((error) => error.line > 0)
+      .map((error) => {
+        const start = Math.min(offset(lines, error.line, error.column), text.length);
+
+        let end = start;
+        if (error.endLine > 0) {
+          end = Math.min(offset(lines, error.endLine, error.endColumn), text.length);
+        }
+        if (end <= start) {
+          // Only the start is known, highlight the token starting there
+          const token = /^[^\s,;=:()<>{}\[\]]*/.exec(text.slice(start))[0];
+          end = start + Math.max(token.length, 1);
+        }
+
+        return { start, end, message: error.message };
+      })
+      .sort((a, b) => a.start - b.start);
+
+    let last = 0;
+    return res.filter((range) => {
+      if (range.start < last) {
+        return false;
+      }
+      last = range.end;
+      return true;
+    });
+  }
+
+  function render(editor) {
+    const textarea = editor.querySelector("textarea");
+    const backdrop = editor.querySelector(".schema-editor-backdrop");
+    const gutter = editor.querySelector(".schema-editor-gutter");
+
+    const text = textarea.value;
+    const errors = schemaErrors();
+
+    // Backdrop
+
+    let html = "";
+    let pos = 0;
+    for (const range of ranges(text, errors)) {
+      html += escapeHTML(text.slice(pos, range.start));
+      html += `<mark title="${escapeHTML(range.message)}">${escapeHTML(text.slice(range.start, range.end)) || " "}</mark>`;
+      pos = range.end;
+    }
+    // The trailing space keeps the height of a final empty line
+    html += escapeHTML(text.slice(pos)) + " ";
+
+    backdrop.innerHTML = html;
+
+    // Gutter
+
+    const errorLines = new Map();
+    for (const error of errors) {
+      if (!errorLines.has(error.line)) {
+        errorLines.set(error.line, error.message);
+      }
+    }
+
+    const count = text.split("\n").length;
+    let gutterHTML = "";
+    for (let line = 1; line <= count; line++) {
+      if (errorLines.has(line)) {
+        gutterHTML += `<div class="schema-editor-gutter-error" title="${escapeHTML(errorLines.get(line))}">${line}</div>`;
+      } else {
+        gutterHTML += `<div>${line}</div>`;
+      }
+    }
+
+    gutter.innerHTML = gutterHTML;
+
+    scroll(editor);
+  }
+
+  function scroll(editor) {
+    const textarea = editor.querySelector("textarea");
+
+    editor.querySelector(".schema-editor-backdrop").scrollTop = textarea.scrollTop;
+    editor.querySelector(".schema-editor-backdrop").scrollLeft = textarea.scrollLeft;
+    editor.querySelector(".schema-editor-gutter").scrollTop = textarea.scrollTop;
+  }
+
+  // setup wraps textarea in an editor, or reuses the editor when the textarea was swapped by htmx.
+  function setup(textarea) {
+    let editor = textarea.closest(".schema-editor");
+    if (!editor) {
+      editor = document.createElement("div");
+      editor.className = "schema-editor";
+      editor.innerHTML = `<div class="schema-editor-gutter" aria-hidden="true"></div><div class="schema-editor-content"><div class="schema-editor-backdrop" aria-hidden="true"></div></div>`;
+
+      textarea.parentElement.insertBefore(editor, textarea);
+      editor.querySelector(".schema-editor-content").appendChild(textarea);
+    }
+
+    textarea.setAttribute("wrap", "off");
+    textarea.setAttribute("spellcheck", "false");
+    textarea.addEventListener("input", () => render(editor));
+    textarea.addEventListener("scroll", () => scroll(editor));
+
+    render(editor);
+  }
+
+  htmx.onLoad((elt) => {
+    const textarea = elt.id === "schema-input" ? elt : elt.querySelector("#schema-input");
+    if (textarea) {
+      setup(textarea);
+    }
+  });
+
+  // The errors are swapped out of band after each evaluation
+  document.addEventListener("htmx:afterSettle", () => {
+    const editor = document.querySelector(".schema-editor");
+    if (editor) {
+      render(editor);
+    }
+  });
+})();
@@ -1,6 +1,12 @@
 package assets
 
-import "net/http"
+import (
+	"io/fs"
+	"net/http"
+)
+
+// FS contains the assets, FileServer serves it.
+var FS fs.FS
 
 // FileServer serves assets either from:
 // * the local file system in non-release builds
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vrischmann/hutil/v3"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authRealm is the realm of the basic authentication.
+const authRealm = "Cassandra Partition Calculator"
+
+// authExemptPaths are the paths served without authentication, the health checks are used by orchestrators which can't authenticate.
+var authExemptPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// basicAuthUser is a user of the basic authentication.
+type basicAuthUser struct {
+	// hash is the bcrypt hash of the password
+	hash []byte
+}
+
+// unknownUserHash is compared to the passwords of unknown users so that they take as long as known users.
+var unknownUserHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("unknown user"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// basicAuthUsers are the users allowed to log in, by name.
+type basicAuthUsers map[string]basicAuthUser
+
+// parseBasicAuthUsers parses a users file.
+//
+// The file contains one user per line in the htpasswd bcrypt format, as created by htpasswd -B:
+//
+//	name:$2y$cost$saltandhash
+//
+// Empty lines and lines starting with # are ignored.
+func parseBasicAuthUsers(r io.Reader) (basicAuthUsers, error) {
+	res := make(basicAuthUsers)
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, hash, ok := strings.Cut(line, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: invalid user, expected name:$2y$cost$saltandhash", lineNumber)
+		}
+
+		if !isBcryptHash(hash) {
+			return nil, fmt.Errorf("line %d: invalid password of user %q, expected a bcrypt hash created with htpasswd -B", lineNumber, name)
+		}
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("line %d: invalid password hash of user %q, err: %w", lineNumber, name, err)
+		}
+
+		if _, ok := res[name]; ok {
+			return nil, fmt.Errorf("line %d: duplicate user %q", lineNumber, name)
+		}
+
+		res[name] = basicAuthUser{hash: []byte(hash)}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("no users defined")
+	}
+
+	return res, nil
+}
+
+// isBcryptHash returns true if hash has the prefix of a bcrypt hash: $2y$ like htpasswd, or $2a$ and $2b$.
+func isBcryptHash(hash string) bool {
+	for _, prefix := range []string{"$2y$", "$2a$", "$2b$"} {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadBasicAuthUsers(path string) (basicAuthUsers, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open users file, err: %w", err)
+	}
+	defer f.Close()
+
+	users, err := parseBasicAuthUsers(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse users file %q, err: %w", path, err)
+	}
+
+	return users, nil
+}
+
+// authenticate returns true if the password of the user is correct.
+func (u basicAuthUsers) authenticate(name, password string) bool {
+	user, ok := u[name]
+	if !ok {
+		// Hash anyway so that unknown users take as long as known users
+		bcrypt.CompareHashAndPassword(unknownUserHash(), []byte(password))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword(user.hash, []byte(password)) == nil
+}
+
+// basicAuthCacheTTL is how long verified credentials are remembered.
+const basicAuthCacheTTL = 5 * time.Minute
+
+// basicAuthCache remembers the verified credentials for a while.
+//
+// bcrypt is slow on purpose but the browsers send the credentials with every request,
+// including the assets and the live evaluations of the form.
+// The credentials are remembered by their HMAC with a random key, never in clear.
+type basicAuthCache struct {
+	users basicAuthUsers
+	key   []byte
+	now   func() time.Time
+
+	mu       sync.Mutex
+	verified map[string]time.Time
+}
+
+func newBasicAuthCache(users basicAuthUsers) (*basicAuthCache, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("unable to generate the key of the credentials cache, err: %w", err)
+	}
+
+	return &basicAuthCache{
+		users:    users,
+		key:      key,
+		now:      time.Now,
+		verified: make(map[string]time.Time),
+	}, nil
+}
+
+// authenticate returns true if the password of the user is correct, like basicAuthUsers.authenticate.
+func (c *basicAuthCache) authenticate(name, password string) bool {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(password))
+	credentials := string(mac.Sum(nil))
+
+	now := c.now()
+
+	c.mu.Lock()
+	expiry, ok := c.verified[credentials]
+	c.mu.Unlock()
+	if ok && now.Before(expiry) {
+		return true
+	}
+
+	if !c.users.authenticate(name, password) {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, expiry := range c.verified {
+		if !now.Before(expiry) {
+			delete(c.verified, key)
+		}
+	}
+	c.verified[credentials] = now.Add(basicAuthCacheTTL)
+
+	return true
+}
+
+// newAuthMiddleware creates the authentication middleware, it returns nil if the authentication is disabled.
+//
+// The users are authenticated either with the basic authentication or by an upstream proxy which sets the trusted header.
+// The trusted header must only be used if the server is not reachable without going through the proxy.
+func (c *serveCommandConfig) newAuthMiddleware() (hutil.Middleware, error) {
+	switch {
+	case c.authUsersPath != "" && c.authTrustedHeader != "":
+		return nil, errors.New("the users file and the trusted header authentications are mutually exclusive")
+
+	case c.authUsersPath != "":
+		users, err := loadBasicAuthUsers(c.authUsersPath)
+		if err != nil {
+			return nil, err
+		}
+		cache, err := newBasicAuthCache(users)
+		if err != nil {
+			return nil, err
+		}
+		return newAuthMiddleware(func(req *http.Request) (string, bool) {
+			name, password, ok := req.BasicAuth()
+			if !ok || !cache.authenticate(name, password) {
+				return "", false
+			}
+			return name, true
+		}, true), nil
+
+	case c.authTrustedHeader != "":
+		header := c.authTrustedHeader
+		return newAuthMiddleware(func(req *http.Request) (string, bool) {
+			name := req.Header.Get(header)
+			return name, name != ""
+		}, false), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// newAuthMiddleware rejects the requests which authenticate returns false for.
+// With basic the client is asked for the basic authentication credentials.
+//
+// The name of the authenticated user is added to the logs of the request.
+func newAuthMiddleware(authenticate func(req *http.Request) (string, bool), basic bool) hutil.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if authExemptPaths[req.URL.Path] {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			user, ok := authenticate(req)
+			if !ok {
+				if basic {
+					w.Header().Set("WWW-Authenticate", `Basic realm="`+authRealm+`", charset="UTF-8"`)
+				}
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			ctx := req.Context()
+			if logger, ok := ctx.Value(loggerContextKey).(*zap.Logger); ok {
+				ctx = context.WithValue(ctx, loggerContextKey, logger.With(zap.String("user", user)))
+			}
+
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// newSameOriginMiddleware rejects the requests changing the state which come from another site.
+//
+// The browsers send the credentials of the basic authentication and the cookies with the cross site requests too,
+// so another site could make the browser of a user save or delete scenarios on their behalf.
+func newSameOriginMiddleware() hutil.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch req.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				if !isSameOriginRequest(req) {
+					http.Error(w, "cross origin request rejected", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// isSameOriginRequest returns true if the request comes from a page of this server or not from a browser.
+//
+// The Sec-Fetch-Site header is used if the browser sends it, otherwise the Origin header is compared to the host.
+// Requests with neither header don't come from a browser.
+func isSameOriginRequest(req *http.Request) bool {
+	if site := req.Header.Get("Sec-Fetch-Site"); site != "" {
+		// none is a navigation by the user, for example a bookmark
+		return site == "same-origin" || site == "none"
+	}
+
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == req.Host
+}
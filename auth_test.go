@@ -0,0 +1,232 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/crypto/bcrypt"
+
+	"rischmann.fr/cassandra-partition-calculator/scenario"
+)
+
+// htpasswdHash returns the bcrypt hash of password in the htpasswd format.
+func htpasswdHash(t testing.TB, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	// htpasswd -B uses the $2y$ prefix, bcrypt.GenerateFromPassword $2a$
+	return "$2y$" + strings.TrimPrefix(string(hash), "$2a$")
+}
+
+func testUsersFile(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "users")
+	data := strings.Join([]string{
+		"# Users of the calculator",
+		"",
+		"alice:" + htpasswdHash(t, "wonderland"),
+		"bob:" + htpasswdHash(t, "builder"),
+	}, "\n")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	return path
+}
+
+func TestParseBasicAuthUsers(t *testing.T) {
+	users, err := loadBasicAuthUsers(testUsersFile(t))
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	require.True(t, users.authenticate("alice", "wonderland"))
+	require.False(t, users.authenticate("alice", "builder"))
+	require.True(t, users.authenticate("bob", "builder"))
+	require.False(t, users.authenticate("carol", "wonderland"))
+
+	testCases := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"empty", "# nothing\n", "no users defined"},
+		{"no-password", "alice\n", "line 1: invalid user"},
+		{"plain-password", "alice:wonderland\n", `line 1: invalid password of user "alice"`},
+		{"sha1-password", "alice:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n", `line 1: invalid password of user "alice"`},
+		{"invalid-hash", "alice:$2y$zz\n", `line 1: invalid password hash of user "alice"`},
+		{"duplicate", "bob:" + htpasswdHash(t, "a") + "\nbob:" + htpasswdHash(t, "b"), `line 2: duplicate user "bob"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseBasicAuthUsers(strings.NewReader(tc.data))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestBasicAuthCache(t *testing.T) {
+	users, err := loadBasicAuthUsers(testUsersFile(t))
+	require.NoError(t, err)
+
+	cache, err := newBasicAuthCache(users)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	require.True(t, cache.authenticate("alice", "wonderland"))
+	require.False(t, cache.authenticate("alice", "builder"))
+	require.False(t, cache.authenticate("carol", "wonderland"))
+	require.Len(t, cache.verified, 1)
+
+	// The verified credentials are not checked again until they expire
+	cache.users = basicAuthUsers{}
+	now = now.Add(basicAuthCacheTTL - time.Second)
+	require.True(t, cache.authenticate("alice", "wonderland"))
+	require.False(t, cache.authenticate("alice", "wonderland2"))
+
+	now = now.Add(time.Second)
+	require.False(t, cache.authenticate("alice", "wonderland"))
+
+	// The expired credentials are removed
+	cache.users = users
+	require.True(t, cache.authenticate("bob", "builder"))
+	require.Len(t, cache.verified, 1)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	t.Run("users-file", func(t *testing.T) {
+		c := newTestServeCommandConfig(t)
+		c.authUsersPath = testUsersFile(t)
+
+		middleware, err := c.newAuthMiddleware()
+		require.NoError(t, err)
+		handler := middleware(c.routes())
+
+		rec := doRequest(t, handler, http.MethodGet, "/", "", "")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+		require.Equal(t, `Basic realm="Cassandra Partition Calculator", charset="UTF-8"`, rec.Header().Get("WWW-Authenticate"))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth("alice", "builder")
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth("alice", "wonderland")
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		// The health checks don't require authentication
+		rec = doRequest(t, handler, http.MethodGet, "/healthz", "", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("trusted-header", func(t *testing.T) {
+		core, logs := observer.New(zapcore.DebugLevel)
+
+		c := newTestServeCommandConfig(t)
+		c.root.logger = zap.New(core)
+		c.authTrustedHeader = "X-Forwarded-User"
+
+		middleware, err := c.newAuthMiddleware()
+		require.NoError(t, err)
+		handler := newLoggingMiddleware(c.root.logger)(middleware(c.routes()))
+
+		rec := doRequest(t, handler, http.MethodGet, "/", "", "")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+		require.Empty(t, rec.Header().Get("WWW-Authenticate"))
+
+		req := httptest.NewRequest(http.MethodGet, "/?state=foobar", nil)
+		req.Header.Set("X-Forwarded-User", "alice")
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		// The logs of the handlers contain the user
+		entries := logs.FilterMessage("unable to decode permalink").All()
+		require.Len(t, entries, 1)
+		require.Equal(t, "alice", entries[0].ContextMap()["user"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c := newTestServeCommandConfig(t)
+
+		middleware, err := c.newAuthMiddleware()
+		require.NoError(t, err)
+		require.Nil(t, middleware)
+	})
+
+	t.Run("exclusive", func(t *testing.T) {
+		c := newTestServeCommandConfig(t)
+		c.authUsersPath = testUsersFile(t)
+		c.authTrustedHeader = "X-Forwarded-User"
+
+		_, err := c.newAuthMiddleware()
+		require.ErrorContains(t, err, "mutually exclusive")
+	})
+}
+
+func TestSameOriginMiddleware(t *testing.T) {
+	c := newTestScenariosServeCommandConfig(t)
+
+	handler, err := c.handler()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		exp     int
+	}{
+		{"same-origin", http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-origin"}, http.StatusSeeOther},
+		{"cross-site", http.MethodPost, map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+		{"same-site", http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-site"}, http.StatusForbidden},
+		{"same-origin-header", http.MethodPost, map[string]string{"Origin": "http://example.com"}, http.StatusSeeOther},
+		{"cross-origin-header", http.MethodPost, map[string]string{"Origin": "https://evil.example"}, http.StatusForbidden},
+		{"null-origin", http.MethodPost, map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{"not-a-browser", http.MethodPost, nil, http.StatusSeeOther},
+		{"cross-site-get", http.MethodGet, map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			saved, err := c.scenarios.Save(scenario.Scenario{Name: "events", Schema: "CREATE TABLE events(id uuid PRIMARY KEY);", Rows: 10})
+			require.NoError(t, err)
+
+			target := "/scenarios/" + strconv.FormatUint(saved.ID, 10)
+			if tc.method == http.MethodPost {
+				target += "/delete"
+			}
+
+			req := httptest.NewRequest(tc.method, target, nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.exp, rec.Code)
+
+			// The scenario is only deleted by the accepted requests
+			_, err = c.scenarios.Get(saved.ID)
+			if tc.exp == http.StatusSeeOther {
+				require.ErrorIs(t, err, scenario.ErrNotFound)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
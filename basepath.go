@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/vrischmann/hutil/v3"
+)
+
+// forwardedPrefixHeader is the header set by a reverse proxy serving the application under a path prefix it strips.
+const forwardedPrefixHeader = "X-Forwarded-Prefix"
+
+// parseBaseURL validates the base URL of the application, it can be a full URL or only a path.
+// The trailing slash is removed so that paths can be appended to the base URL.
+func parseBaseURL(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q, err: %w", s, err)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid base URL %q, it can't have a query or a fragment", s)
+	}
+	if p := strings.TrimSuffix(u.Path, "/"); p != "" && !isValidBasePath(p) {
+		return "", fmt.Errorf("invalid base URL %q, invalid path %q", s, u.Path)
+	}
+
+	return strings.TrimSuffix(s, "/"), nil
+}
+
+// isValidBasePath returns true if p is a clean absolute path without a trailing slash.
+func isValidBasePath(p string) bool {
+	return strings.HasPrefix(p, "/") && !strings.HasPrefix(p, "//") &&
+		path.Clean(p) == p && p != "/" &&
+		!strings.ContainsAny(p, "?#\"'<>\\ ")
+}
+
+// basePath returns the path of the base URL, empty if the application is served at the root.
+func (c *serveCommandConfig) basePath() string {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// forwardedBaseURL returns the base URL of the application for a request forwarded with the prefix.
+// The scheme and host of the configured base URL are kept, only its path is replaced.
+func (c *serveCommandConfig) forwardedBaseURL(prefix string) string {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return prefix
+	}
+	u.Path, u.RawPath = prefix, ""
+
+	return u.String()
+}
+
+type baseURLContextKey struct{}
+
+// baseURLOf returns the base URL of the links of the request of ctx.
+func (c *serveCommandConfig) baseURLOf(ctx context.Context) string {
+	if baseURL, ok := ctx.Value(baseURLContextKey{}).(string); ok {
+		return baseURL
+	}
+	return c.baseURL
+}
+
+// newBasePathMiddleware mounts the routes under the base path of the application.
+//
+// Two kinds of reverse proxies serving the application under a path prefix are supported:
+//   - the proxy forwards the requests as is: the path of the base URL is removed from the request path
+//   - the proxy removes the prefix and sets it in the X-Forwarded-Prefix header: the links of the response use the prefix
+//
+// The X-Forwarded-Prefix header is only used with the -trust-forwarded-prefix flag, otherwise any client could change
+// the links, the redirections and the cookies of the responses.
+//
+// Requests without the prefix are still served so that the health checks and the metrics can be reached directly.
+func (c *serveCommandConfig) newBasePathMiddleware() hutil.Middleware {
+	basePath := c.basePath()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			prefix := basePath
+			if forwarded := strings.TrimSuffix(req.Header.Get(forwardedPrefixHeader), "/"); c.trustForwardedPrefix && isValidBasePath(forwarded) {
+				prefix = forwarded
+
+				ctx := context.WithValue(req.Context(), baseURLContextKey{}, c.forwardedBaseURL(prefix))
+				req = req.WithContext(ctx)
+			}
+
+			if prefix != "" && (req.URL.Path == prefix || strings.HasPrefix(req.URL.Path, prefix+"/")) {
+				req = stripPathPrefix(req, prefix)
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// stripPathPrefix returns a shallow copy of req without the prefix in its URL path, like http.StripPrefix.
+func stripPathPrefix(req *http.Request, prefix string) *http.Request {
+	res := new(http.Request)
+	*res = *req
+	res.URL = new(url.URL)
+	*res.URL = *req.URL
+
+	res.URL.Path = strings.TrimPrefix(req.URL.Path, prefix)
+	if res.URL.Path == "" {
+		res.URL.Path = "/"
+	}
+	if req.URL.RawPath != "" {
+		res.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, prefix)
+		if res.URL.RawPath == "" {
+			res.URL.RawPath = "/"
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBaseURL(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   string
+		err   string
+	}{
+		{"", "", ""},
+		{"/", "", ""},
+		{"/calculator", "/calculator", ""},
+		{"/tools/calculator/", "/tools/calculator", ""},
+		{"https://example.com", "https://example.com", ""},
+		{"https://example.com/calculator/", "https://example.com/calculator", ""},
+		{"calculator", "", `invalid path "calculator"`},
+		{"/tools/../calculator", "", `invalid path "/tools/../calculator"`},
+		{"/calculator?foo=bar", "", "it can't have a query or a fragment"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			res, err := parseBaseURL(tc.input)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, res)
+		})
+	}
+}
+
+// newTestProxy starts a reverse proxy serving the backend under prefix.
+// If strip is true the proxy removes the prefix from the path and sets the X-Forwarded-Prefix header.
+func newTestProxy(t *testing.T, backend *httptest.Server, prefix string, strip bool) *httptest.Server {
+	t.Helper()
+
+	backendURL, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+
+	proxy := httptest.NewServer(&httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			if strip {
+				r.Out.URL.Path = strings.TrimPrefix(r.Out.URL.Path, prefix)
+				r.Out.URL.RawPath = ""
+				r.Out.Header.Set(forwardedPrefixHeader, prefix)
+			}
+			r.SetURL(backendURL)
+		},
+	})
+	t.Cleanup(proxy.Close)
+
+	return proxy
+}
+
+func newTestBackend(t *testing.T, c *serveCommandConfig) *httptest.Server {
+	t.Helper()
+
+	handler, err := c.handler()
+	require.NoError(t, err)
+
+	backend := httptest.NewServer(handler)
+	t.Cleanup(backend.Close)
+
+	return backend
+}
+
+func proxyGet(t *testing.T, rawURL string, headers ...string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	require.NoError(t, err)
+	for i := 0; i < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	return doProxyRequest(t, req)
+}
+
+func proxyEvaluate(t *testing.T, rawURL string) (int, string) {
+	t.Helper()
+
+	form := url.Values{
+		"schema": {"CREATE TABLE events(user_id uuid PRIMARY KEY, name text, created_at timestamp);"},
+		"rows":   {"10"},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, rawURL, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+
+	return doProxyRequest(t, req)
+}
+
+func doProxyRequest(t *testing.T, req *http.Request) (int, string) {
+	t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, string(body)
+}
+
+var (
+	permalinkRegexp  = regexp.MustCompile(`<a id="permalink" href="([^"]+)"`)
+	exportLinkRegexp = regexp.MustCompile(`href="([^"]+format=csv[^"]*)"`)
+)
+
+// checkProxiedCalculator checks that the calculator works through the proxy, with all its links starting with prefix.
+// linkBase is the base of the absolute links, the same as prefix if the links are relative to the host.
+func checkProxiedCalculator(t *testing.T, proxyURL, prefix, linkBase string) {
+	t.Helper()
+
+	// The page links to the prefixed routes
+
+	status, body := proxyGet(t, proxyURL+prefix+"/")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `href="`+linkBase+`/assets/style.css"`)
+	require.Contains(t, body, `src="`+linkBase+`/assets/htmx.min.js"`)
+	require.Contains(t, body, `hx-post="`+linkBase+`/evaluate"`)
+	require.Contains(t, body, `hx-get="`+linkBase+`/examples"`)
+	require.Contains(t, body, `href="`+linkBase+`/compare"`)
+
+	// Without the trailing slash too
+
+	status, _ = proxyGet(t, proxyURL+prefix)
+	require.Equal(t, http.StatusOK, status)
+
+	// Which are served
+
+	status, body = proxyGet(t, proxyURL+prefix+"/assets/style.css")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "#error-messages")
+
+	status, body = proxyGet(t, proxyURL+prefix+"/examples?example=messaging", "HX-Request", "true")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "CREATE TABLE messages(")
+
+	status, body = proxyGet(t, proxyURL+prefix+"/compare")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `action="`+linkBase+`/compare"`)
+
+	status, _ = proxyGet(t, proxyURL+prefix+"/?example=foo")
+	require.Equal(t, http.StatusNotFound, status)
+
+	// The results link to the prefixed permalink and exports
+
+	status, body = proxyEvaluate(t, proxyURL+prefix+"/evaluate")
+	require.Equal(t, http.StatusOK, status)
+
+	permalink := permalinkRegexp.FindStringSubmatch(body)
+	require.NotNil(t, permalink, "no permalink in %s", body)
+	require.True(t, strings.HasPrefix(permalink[1], linkBase+"/?state="), permalink[1])
+
+	exportLink := exportLinkRegexp.FindStringSubmatch(body)
+	require.NotNil(t, exportLink, "no export link in %s", body)
+	require.True(t, strings.HasPrefix(exportLink[1], linkBase+"/export?"), exportLink[1])
+
+	pathOf := func(link string) string {
+		return strings.TrimPrefix(html.UnescapeString(link), strings.TrimSuffix(linkBase, prefix))
+	}
+
+	status, body = proxyGet(t, proxyURL+pathOf(permalink[1]))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "CREATE TABLE events(")
+
+	status, body = proxyGet(t, proxyURL+pathOf(exportLink[1]))
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "events")
+}
+
+func TestBasePathBehindProxy(t *testing.T) {
+	t.Run("prefix-kept", func(t *testing.T) {
+		c := newTestServeCommandConfig(t)
+		c.baseURL = "/calculator"
+
+		backend := newTestBackend(t, c)
+		proxy := newTestProxy(t, backend, "/calculator", false)
+
+		checkProxiedCalculator(t, proxy.URL, "/calculator", "/calculator")
+
+		// The health checks are also served without the prefix
+		status, _ := proxyGet(t, backend.URL+"/healthz")
+		require.Equal(t, http.StatusOK, status)
+		status, _ = proxyGet(t, proxy.URL+"/calculator/healthz")
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("prefix-kept-full-url", func(t *testing.T) {
+		c := newTestServeCommandConfig(t)
+		c.baseURL = "https://calc.example.com/tools/calculator"
+
+		backend := newTestBackend(t, c)
+		proxy := newTestProxy(t, backend, "/tools/calculator", false)
+
+		checkProxiedCalculator(t, proxy.URL, "/tools/calculator", "https://calc.example.com/tools/calculator")
+	})
+
+	t.Run("prefix-stripped", func(t *testing.T) {
+		c := newTestServeCommandConfig(t)
+		c.trustForwardedPrefix = true
+
+		backend := newTestBackend(t, c)
+		proxy := newTestProxy(t, backend, "/tools/calculator", true)
+
+		checkProxiedCalculator(t, proxy.URL, "/tools/calculator", "/tools/calculator")
+
+		// Requests reaching the backend directly are not prefixed
+		status, body := proxyGet(t, backend.URL+"/")
+		require.Equal(t, http.StatusOK, status)
+		require.Contains(t, body, `href="/assets/style.css"`)
+	})
+
+	t.Run("prefix-stripped-full-url", func(t *testing.T) {
+		c := newTestServeCommandConfig(t)
+		c.baseURL = "https://calc.example.com"
+		c.trustForwardedPrefix = true
+
+		backend := newTestBackend(t, c)
+		proxy := newTestProxy(t, backend, "/calculator", true)
+
+		checkProxiedCalculator(t, proxy.URL, "/calculator", "https://calc.example.com/calculator")
+	})
+
+	t.Run("invalid-forwarded-prefix", func(t *testing.T) {
+		c := newTestServeCommandConfig(t)
+		c.baseURL = "/calculator"
+		c.trustForwardedPrefix = true
+
+		backend := newTestBackend(t, c)
+
+		for _, prefix := range []string{"//evil.example.com", "/foo/../bar", `/foo"bar`, "foo"} {
+			status, body := proxyGet(t, backend.URL+"/calculator/", forwardedPrefixHeader, prefix)
+			require.Equal(t, http.StatusOK, status)
+			require.Contains(t, body, `href="/calculator/assets/style.css"`)
+		}
+	})
+	t.Run("untrusted-forwarded-prefix", func(t *testing.T) {
+		c := newTestServeCommandConfig(t)
+		c.baseURL = "/calculator"
+
+		backend := newTestBackend(t, c)
+
+		// The header is ignored by default
+		status, body := proxyGet(t, backend.URL+"/calculator/", forwardedPrefixHeader, "/evil")
+		require.Equal(t, http.StatusOK, status)
+		require.Contains(t, body, `href="/calculator/assets/style.css"`)
+		require.NotContains(t, body, "/evil")
+
+		// Even when set by a proxy
+		proxy := newTestProxy(t, newTestBackend(t, newTestServeCommandConfig(t)), "/tools/calculator", true)
+
+		status, body = proxyGet(t, proxy.URL+"/tools/calculator/")
+		require.Equal(t, http.StatusOK, status)
+		require.Contains(t, body, `href="/assets/style.css"`)
+	})
+}
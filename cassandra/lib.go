@@ -9,6 +9,12 @@ import (
 type Estimation struct {
 	Values int
 	Bytes  int
+
+	// Breakdown of Bytes
+	PartitionKeyBytes  int
+	ClusteringKeyBytes int
+	MetadataBytes      int
+	RowsBytes          int
 }
 
 func sumColumnsSize(columns cql.ColumnDefinitions) int64 {
@@ -21,6 +27,30 @@ func sumColumnsSize(columns cql.ColumnDefinitions) int64 {
 	return result
 }
 
+// Limits of a partition.
+const (
+	// RecommendedMaxPartitionBytes is the size above which a partition is considered too large.
+	RecommendedMaxPartitionBytes = 100 * 1024 * 1024
+	// RecommendedMaxPartitionValues is the number of values above which a partition is considered too large.
+	RecommendedMaxPartitionValues = 100_000
+	// MaxPartitionValues is the hard limit of the number of values in a partition.
+	MaxPartitionValues = 2_000_000_000
+)
+
+// Thresholds are the limits above which a partition is considered too large.
+type Thresholds struct {
+	MaxPartitionBytes  int64
+	MaxPartitionValues int64
+}
+
+// DefaultThresholds returns the recommended thresholds.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MaxPartitionBytes:  RecommendedMaxPartitionBytes,
+		MaxPartitionValues: RecommendedMaxPartitionValues,
+	}
+}
+
 var (
 	ErrMissingEstimatedColumn = errors.New("missing estimated column")
 )
@@ -53,5 +83,10 @@ func Estimate(schema cql.Schema, rows int64) (res Estimation, err error) {
 	res.Values = int(values)
 	res.Bytes = int(totalSize)
 
+	res.PartitionKeyBytes = int(partitionKeySize)
+	res.ClusteringKeyBytes = int(clusteringKeySize)
+	res.MetadataBytes = int(metadataSize)
+	res.RowsBytes = int(rowsSize)
+
 	return res, nil
 }
@@ -30,6 +30,8 @@ func TestEstimate(t *testing.T) {
 	result, err := Estimate(schema, 5_000_000)
 	require.NoError(t, err)
 
+	require.Equal(t, result.Bytes, result.PartitionKeyBytes+result.ClusteringKeyBytes+result.MetadataBytes+result.RowsBytes)
+
 	spew.Dump(result)
 	fmt.Printf("values: %d, bytes: %s\n", result.Values, humanize.Bytes(uint64(result.Bytes)))
 }
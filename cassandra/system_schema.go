@@ -0,0 +1,405 @@
+package cassandra
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+// SystemSchemaFormat is the format of an export of the system_schema tables.
+type SystemSchemaFormat string
+
+const (
+	// SystemSchemaCSV is the format produced by cqlsh with COPY system_schema.<table> TO '<file>' WITH HEADER = true
+	SystemSchemaCSV SystemSchemaFormat = "csv"
+	// SystemSchemaJSON is either a JSON array of rows or one JSON object per line, as produced by SELECT JSON.
+	SystemSchemaJSON SystemSchemaFormat = "json"
+)
+
+// SystemSchemaExport contains the exports of the system_schema tables.
+// Only Columns is mandatory.
+type SystemSchemaExport struct {
+	Format SystemSchemaFormat
+
+	Tables  io.Reader
+	Columns io.Reader
+	Types   io.Reader
+}
+
+var (
+	ErrMissingSystemSchemaColumns = errors.New("missing system_schema.columns export")
+)
+
+type systemSchemaRow map[string]string
+
+// tableOptions are the table options imported from system_schema.tables, in the order DESCRIBE prints them.
+// The boolean is true if the value is a string that must be quoted.
+var tableOptions = []struct {
+	name   string
+	quoted bool
+}{
+	{"additional_write_policy", true},
+	{"bloom_filter_fp_chance", false},
+	{"caching", false},
+	{"cdc", false},
+	{"comment", true},
+	{"compaction", false},
+	{"compression", false},
+	{"crc_check_chance", false},
+	{"default_time_to_live", false},
+	{"gc_grace_seconds", false},
+	{"max_index_interval", false},
+	{"memtable_flush_period_in_ms", false},
+	{"min_index_interval", false},
+	{"read_repair", true},
+	{"speculative_retry", true},
+}
+
+// ImportSystemSchema builds the tables and user defined types described by exports of the
+// system_schema.tables, system_schema.columns and system_schema.types tables.
+func ImportSystemSchema(export SystemSchemaExport) (tables []cql.Schema, types []cql.UserType, err error) {
+	if export.Columns == nil {
+		return nil, nil, ErrMissingSystemSchemaColumns
+	}
+
+	columnRows, err := readSystemSchemaRows(export.Format, export.Columns)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read columns, err: %w", err)
+	}
+
+	var tableRows, typeRows []systemSchemaRow
+	if export.Tables != nil {
+		if tableRows, err = readSystemSchemaRows(export.Format, export.Tables); err != nil {
+			return nil, nil, fmt.Errorf("unable to read tables, err: %w", err)
+		}
+	}
+	if export.Types != nil {
+		if typeRows, err = readSystemSchemaRows(export.Format, export.Types); err != nil {
+			return nil, nil, fmt.Errorf("unable to read types, err: %w", err)
+		}
+	}
+
+	//
+	// Build the tables from the columns
+	//
+
+	type tableKey struct {
+		keyspace string
+		table    string
+	}
+	type importedColumn struct {
+		column   cql.ColumnDefinition
+		kind     string
+		position int
+		order    string
+	}
+
+	columnsByTable := make(map[tableKey][]importedColumn)
+	for _, row := range columnRows {
+		key := tableKey{row["keyspace_name"], row["table_name"]}
+
+		position, _ := strconv.Atoi(row["position"])
+
+		columnsByTable[key] = append(columnsByTable[key], importedColumn{
+			column: cql.ColumnDefinition{
+				Name:   row["column_name"],
+				Type:   cql.DataType{Name: normalizeTypeName(row["type"])},
+				Static: row["kind"] == "static",
+			},
+			kind:     row["kind"],
+			position: position,
+			order:    row["clustering_order"],
+		})
+	}
+
+	tableRowsByKey := make(map[tableKey]systemSchemaRow, len(tableRows))
+	for _, row := range tableRows {
+		tableRowsByKey[tableKey{row["keyspace_name"], row["table_name"]}] = row
+	}
+
+	keys := make([]tableKey, 0, len(columnsByTable))
+	for key := range columnsByTable {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].keyspace != keys[j].keyspace {
+			return keys[i].keyspace < keys[j].keyspace
+		}
+		return keys[i].table < keys[j].table
+	})
+
+	kindOrder := map[string]int{
+		"partition_key": 0,
+		"clustering":    1,
+	}
+	kindRank := func(kind string) int {
+		if rank, ok := kindOrder[kind]; ok {
+			return rank
+		}
+		return 2
+	}
+
+	for _, key := range keys {
+		columns := columnsByTable[key]
+
+		// Same order as DESCRIBE: partition key, clustering key, then the rest by name
+		sort.SliceStable(columns, func(i, j int) bool {
+			a, b := columns[i], columns[j]
+			if kindRank(a.kind) != kindRank(b.kind) {
+				return kindRank(a.kind) < kindRank(b.kind)
+			}
+			if a.position != b.position {
+				return a.position < b.position
+			}
+			return a.column.Name < b.column.Name
+		})
+
+		schema := cql.Schema{
+			Keyspace:  key.keyspace,
+			TableName: key.table,
+		}
+
+		hasDescending := false
+		for _, column := range columns {
+			schema.Columns = append(schema.Columns, column.column)
+
+			switch column.kind {
+			case "partition_key":
+				schema.PrimaryKey.PartitionKey.Columns = append(schema.PrimaryKey.PartitionKey.Columns, column.column)
+			case "clustering":
+				schema.PrimaryKey.ClusteringKey.Columns = append(schema.PrimaryKey.ClusteringKey.Columns, column.column)
+
+				descending := strings.EqualFold(column.order, "desc")
+				hasDescending = hasDescending || descending
+
+				schema.ClusteringOrder = append(schema.ClusteringOrder, cql.ClusteringOrder{
+					Column:     column.column.Name,
+					Descending: descending,
+				})
+			}
+		}
+		if !hasDescending {
+			schema.ClusteringOrder = nil
+		}
+
+		if row, ok := tableRowsByKey[key]; ok {
+			for _, option := range tableOptions {
+				value, ok := row[option.name]
+				if !ok || (value == "" && !option.quoted) {
+					continue
+				}
+				if option.quoted {
+					value = cql.QuoteString(value)
+				}
+
+				schema.Options = append(schema.Options, cql.Option{
+					Name:  option.name,
+					Value: value,
+				})
+			}
+		}
+
+		tables = append(tables, schema)
+	}
+
+	//
+	// Build the types
+	//
+
+	for _, row := range typeRows {
+		typ := cql.UserType{
+			Keyspace: row["keyspace_name"],
+			Name:     row["type_name"],
+		}
+
+		fieldNames := parseCQLList(row["field_names"])
+		fieldTypes := parseCQLList(row["field_types"])
+		if len(fieldNames) != len(fieldTypes) {
+			return nil, nil, fmt.Errorf("type %q has %d field names but %d field types", typ.QualifiedName(), len(fieldNames), len(fieldTypes))
+		}
+
+		for i := range fieldNames {
+			typ.Fields = append(typ.Fields, cql.ColumnDefinition{
+				Name: fieldNames[i],
+				Type: cql.DataType{Name: normalizeTypeName(fieldTypes[i])},
+			})
+		}
+
+		types = append(types, typ)
+	}
+	sort.SliceStable(types, func(i, j int) bool {
+		return types[i].QualifiedName() < types[j].QualifiedName()
+	})
+
+	return tables, types, nil
+}
+
+// normalizeTypeName removes the whitespace in a type name to match what the CQL parser produces.
+func normalizeTypeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// parseCQLList parses a list literal of strings as printed by cqlsh, for example ['a', 'b'].
+func parseCQLList(s string) []string {
+	var (
+		res     []string
+		current strings.Builder
+		quoted  bool
+	)
+
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "[")
+	s = strings.TrimSuffix(s, "]")
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+
+		switch {
+		case ch == '\'' && quoted && i+1 < len(s) && s[i+1] == '\'':
+			current.WriteByte('\'')
+			i++
+		case ch == '\'' && quoted:
+			res = append(res, current.String())
+			current.Reset()
+			quoted = false
+		case ch == '\'':
+			quoted = true
+		case quoted:
+			current.WriteByte(ch)
+		}
+	}
+
+	return res
+}
+
+func readSystemSchemaRows(format SystemSchemaFormat, r io.Reader) ([]systemSchemaRow, error) {
+	switch format {
+	case SystemSchemaCSV:
+		return readSystemSchemaCSV(r)
+	case SystemSchemaJSON:
+		return readSystemSchemaJSON(r)
+	default:
+		return nil, fmt.Errorf("invalid system_schema format %q", format)
+	}
+}
+
+func readSystemSchemaCSV(r io.Reader) ([]systemSchemaRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+
+	res := make([]systemSchemaRow, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(systemSchemaRow, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[strings.TrimSpace(name)] = record[i]
+			}
+		}
+		res = append(res, row)
+	}
+
+	return res, nil
+}
+
+func readSystemSchemaJSON(r io.Reader) ([]systemSchemaRow, error) {
+	var objects []map[string]interface{}
+
+	decoder := json.NewDecoder(r)
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := value.(type) {
+		case []interface{}:
+			for _, elem := range v {
+				object, ok := elem.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("invalid row of type %T", elem)
+				}
+				objects = append(objects, object)
+			}
+		case map[string]interface{}:
+			objects = append(objects, v)
+		default:
+			return nil, fmt.Errorf("invalid row of type %T", value)
+		}
+	}
+
+	res := make([]systemSchemaRow, 0, len(objects))
+	for _, object := range objects {
+		row := make(systemSchemaRow, len(object))
+		for name, value := range object {
+			row[name] = jsonToCQLLiteral(value, false)
+		}
+		res = append(res, row)
+	}
+
+	return res, nil
+}
+
+// jsonToCQLLiteral converts a JSON value to the text cqlsh would print for it.
+// Strings are only quoted when nested in a collection.
+func jsonToCQLLiteral(value interface{}, nested bool) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if nested {
+			return cql.QuoteString(v)
+		}
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		elems := make([]string, 0, len(v))
+		for _, elem := range v {
+			elems = append(elems, jsonToCQLLiteral(elem, true))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		elems := make([]string, 0, len(v))
+		for _, key := range keys {
+			elems = append(elems, cql.QuoteString(key)+": "+jsonToCQLLiteral(v[key], true))
+		}
+		return "{" + strings.Join(elems, ", ") + "}"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,92 @@
+package cassandra
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+const expImportedSchema = `CREATE TABLE tracking.events (
+	tenant_key bigint,
+	user_id uuid,
+	event_id timeuuid,
+	event_data blob,
+	location frozen<address>,
+	tags map<text, int>,
+	PRIMARY KEY ((tenant_key, user_id), event_id)
+) WITH CLUSTERING ORDER BY (event_id DESC)
+	AND caching = {'keys': 'ALL', 'rows_per_partition': 'NONE'}
+	AND comment = 'Client''s events'
+	AND gc_grace_seconds = 864000;
+`
+
+const expImportedType = `CREATE TYPE tracking.address (
+	street text,
+	city text
+);
+`
+
+func TestImportSystemSchemaCSV(t *testing.T) {
+	const tables = `keyspace_name,table_name,caching,comment,gc_grace_seconds
+tracking,events,"{'keys': 'ALL', 'rows_per_partition': 'NONE'}",Client's events,864000
+`
+	const columns = `keyspace_name,table_name,column_name,clustering_order,column_name_bytes,kind,position,type
+tracking,events,event_data,none,0x6576656e745f64617461,regular,-1,blob
+tracking,events,event_id,desc,0x6576656e745f6964,clustering,0,timeuuid
+tracking,events,location,none,0x6c6f636174696f6e,regular,-1,frozen<address>
+tracking,events,tags,none,0x74616773,regular,-1,"map<text, int>"
+tracking,events,tenant_key,none,0x74656e616e745f6b6579,partition_key,0,bigint
+tracking,events,user_id,none,0x757365725f6964,partition_key,1,uuid
+`
+	const types = `keyspace_name,type_name,field_names,field_types
+tracking,address,"['street', 'city']","['text', 'text']"
+`
+
+	tableSchemas, userTypes, err := ImportSystemSchema(SystemSchemaExport{
+		Format:  SystemSchemaCSV,
+		Tables:  strings.NewReader(tables),
+		Columns: strings.NewReader(columns),
+		Types:   strings.NewReader(types),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, tableSchemas, 1)
+	require.Equal(t, expImportedSchema, cql.Format(tableSchemas[0]))
+
+	require.Len(t, userTypes, 1)
+	require.Equal(t, expImportedType, cql.FormatUserType(userTypes[0]))
+}
+
+func TestImportSystemSchemaJSON(t *testing.T) {
+	const tables = `[{"keyspace_name": "tracking", "table_name": "events", "caching": {"keys": "ALL", "rows_per_partition": "NONE"}, "comment": "Client's events", "gc_grace_seconds": 864000}]`
+	const columns = `{"keyspace_name": "tracking", "table_name": "events", "column_name": "event_data", "clustering_order": "none", "kind": "regular", "position": -1, "type": "blob"}
+{"keyspace_name": "tracking", "table_name": "events", "column_name": "event_id", "clustering_order": "desc", "kind": "clustering", "position": 0, "type": "timeuuid"}
+{"keyspace_name": "tracking", "table_name": "events", "column_name": "location", "clustering_order": "none", "kind": "regular", "position": -1, "type": "frozen<address>"}
+{"keyspace_name": "tracking", "table_name": "events", "column_name": "tags", "clustering_order": "none", "kind": "regular", "position": -1, "type": "map<text, int>"}
+{"keyspace_name": "tracking", "table_name": "events", "column_name": "tenant_key", "clustering_order": "none", "kind": "partition_key", "position": 0, "type": "bigint"}
+{"keyspace_name": "tracking", "table_name": "events", "column_name": "user_id", "clustering_order": "none", "kind": "partition_key", "position": 1, "type": "uuid"}
+`
+	const types = `[{"keyspace_name": "tracking", "type_name": "address", "field_names": ["street", "city"], "field_types": ["text", "text"]}]`
+
+	tableSchemas, userTypes, err := ImportSystemSchema(SystemSchemaExport{
+		Format:  SystemSchemaJSON,
+		Tables:  strings.NewReader(tables),
+		Columns: strings.NewReader(columns),
+		Types:   strings.NewReader(types),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, tableSchemas, 1)
+	require.Equal(t, expImportedSchema, cql.Format(tableSchemas[0]))
+
+	require.Len(t, userTypes, 1)
+	require.Equal(t, expImportedType, cql.FormatUserType(userTypes[0]))
+}
+
+func TestImportSystemSchemaMissingColumns(t *testing.T) {
+	_, _, err := ImportSystemSchema(SystemSchemaExport{Format: SystemSchemaCSV})
+	require.ErrorIs(t, err, ErrMissingSystemSchemaColumns)
+}
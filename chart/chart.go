@@ -0,0 +1,290 @@
+// Package chart renders simple line charts as SVG documents.
+//
+// It only implements what the calculator needs: one or more series on logarithmic or linear axes,
+// horizontal threshold lines and vertical markers.
+package chart
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Point struct {
+	X, Y float64
+}
+
+// Series is a line of the chart.
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Line is a horizontal (threshold) or vertical (marker) line.
+type Line struct {
+	Name  string
+	Value float64
+}
+
+// Axis describes how values are placed and labelled on an axis.
+type Axis struct {
+	Label string
+	// Log uses a logarithmic scale, values lower than 1 are clamped to 1.
+	Log bool
+	// Format formats the tick labels, strconv.FormatFloat is used if nil.
+	Format func(float64) string
+}
+
+type Chart struct {
+	Title  string
+	Width  int
+	Height int
+
+	X Axis
+	Y Axis
+
+	Series []Series
+	// Thresholds are drawn as horizontal dashed lines.
+	Thresholds []Line
+	// Markers are drawn as vertical lines.
+	Markers []Line
+}
+
+var ErrNoData = errors.New("no data to draw")
+
+const (
+	marginTop    = 30
+	marginRight  = 20
+	marginBottom = 50
+	marginLeft   = 80
+
+	defaultWidth  = 640
+	defaultHeight = 320
+)
+
+type scale struct {
+	log        bool
+	min, max   float64
+	start, end float64
+}
+
+func (s scale) value(v float64) float64 {
+	if s.log {
+		return math.Log10(math.Max(v, 1))
+	}
+	return v
+}
+
+// position returns the position of v in the drawing area.
+func (s scale) position(v float64) float64 {
+	v = s.value(v)
+	if s.max == s.min {
+		return (s.start + s.end) / 2
+	}
+	return s.start + (v-s.min)/(s.max-s.min)*(s.end-s.start)
+}
+
+// ticks returns the values where a tick is drawn.
+func (s scale) ticks() []float64 {
+	var res []float64
+
+	if s.log {
+		for exp := math.Floor(s.min); exp <= math.Ceil(s.max); exp++ {
+			if exp >= s.min && exp <= s.max {
+				res = append(res, math.Pow(10, exp))
+			}
+		}
+		return res
+	}
+
+	step := niceStep((s.max - s.min) / 5)
+	for v := math.Ceil(s.min/step) * step; v <= s.max; v += step {
+		res = append(res, v)
+	}
+	return res
+}
+
+// niceStep rounds step to 1, 2 or 5 times a power of 10.
+func niceStep(step float64) float64 {
+	if step <= 0 {
+		return 1
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(step)))
+	switch normalized := step / magnitude; {
+	case normalized <= 1:
+		return magnitude
+	case normalized <= 2:
+		return 2 * magnitude
+	case normalized <= 5:
+		return 5 * magnitude
+	default:
+		return 10 * magnitude
+	}
+}
+
+// newScale returns a scale covering values, rounded to powers of 10 for a logarithmic axis.
+func newScale(axis Axis, values []float64, start, end float64) scale {
+	res := scale{
+		log:   axis.Log,
+		min:   math.Inf(1),
+		max:   math.Inf(-1),
+		start: start,
+		end:   end,
+	}
+	for _, v := range values {
+		v = res.value(v)
+		res.min = math.Min(res.min, v)
+		res.max = math.Max(res.max, v)
+	}
+
+	if axis.Log {
+		res.min = math.Floor(res.min)
+		res.max = math.Ceil(res.max)
+	} else if res.min > 0 {
+		res.min = 0
+	}
+	if res.min == res.max {
+		res.max = res.min + 1
+	}
+
+	return res
+}
+
+func (a Axis) format(v float64) string {
+	if a.Format != nil {
+		return a.Format(v)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func coord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// WriteSVG writes the chart as a standalone SVG document.
+func (c Chart) WriteSVG(w io.Writer) error {
+	var xValues, yValues []float64
+	for _, series := range c.Series {
+		for _, point := range series.Points {
+			xValues = append(xValues, point.X)
+			yValues = append(yValues, point.Y)
+		}
+	}
+	if len(xValues) == 0 {
+		return ErrNoData
+	}
+	for _, marker := range c.Markers {
+		xValues = append(xValues, marker.Value)
+	}
+	for _, threshold := range c.Thresholds {
+		yValues = append(yValues, threshold.Value)
+	}
+
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = defaultWidth
+	}
+	if height <= 0 {
+		height = defaultHeight
+	}
+
+	var (
+		left   = float64(marginLeft)
+		right  = float64(width - marginRight)
+		top    = float64(marginTop)
+		bottom = float64(height - marginBottom)
+
+		xScale = newScale(c.X, xValues, left, right)
+		yScale = newScale(c.Y, yValues, bottom, top)
+	)
+
+	bw := bufio.NewWriter(w)
+	printf := func(format string, args ...interface{}) {
+		fmt.Fprintf(bw, format, args...)
+	}
+	escape := html.EscapeString
+
+	printf(`<svg xmlns="http://www.w3.org/2000/svg" class="chart" role="img" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	printf("<title>%s</title>\n", escape(c.Title))
+	printf(`<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+	printf(`<text x="%s" y="%d" font-weight="bold">%s</text>`+"\n", coord(left), marginTop-12, escape(c.Title))
+
+	// Grid and ticks
+
+	for _, tick := range xScale.ticks() {
+		x := coord(xScale.position(tick))
+		printf(`<line class="grid" x1="%s" y1="%s" x2="%s" y2="%s" stroke="#ddd"/>`+"\n", x, coord(top), x, coord(bottom))
+		printf(`<text x="%s" y="%s" text-anchor="middle">%s</text>`+"\n", x, coord(bottom+16), escape(c.X.format(tick)))
+	}
+	for _, tick := range yScale.ticks() {
+		y := coord(yScale.position(tick))
+		printf(`<line class="grid" x1="%s" y1="%s" x2="%s" y2="%s" stroke="#ddd"/>`+"\n", coord(left), y, coord(right), y)
+		printf(`<text x="%s" y="%s" text-anchor="end" dominant-baseline="middle">%s</text>`+"\n", coord(left-6), y, escape(c.Y.format(tick)))
+	}
+
+	// Axes
+
+	printf(`<line class="axis" x1="%s" y1="%s" x2="%s" y2="%s" stroke="black"/>`+"\n", coord(left), coord(bottom), coord(right), coord(bottom))
+	printf(`<line class="axis" x1="%s" y1="%s" x2="%s" y2="%s" stroke="black"/>`+"\n", coord(left), coord(top), coord(left), coord(bottom))
+	printf(`<text x="%s" y="%d" text-anchor="middle">%s</text>`+"\n", coord((left+right)/2), height-10, escape(c.X.Label))
+	printf(`<text x="14" y="%s" text-anchor="middle" transform="rotate(-90 14 %s)">%s</text>`+"\n", coord((top+bottom)/2), coord((top+bottom)/2), escape(c.Y.Label))
+
+	// Thresholds and markers
+
+	for _, threshold := range c.Thresholds {
+		y := coord(yScale.position(threshold.Value))
+		printf(`<line class="threshold" x1="%s" y1="%s" x2="%s" y2="%s" stroke="#c0392b" stroke-dasharray="6 4"/>`+"\n", coord(left), y, coord(right), y)
+		printf(`<text x="%s" y="%s" text-anchor="end" fill="#c0392b" dy="-4">%s</text>`+"\n", coord(right), y, escape(threshold.Name))
+	}
+	for _, marker := range c.Markers {
+		x := coord(xScale.position(marker.Value))
+		printf(`<line class="marker" x1="%s" y1="%s" x2="%s" y2="%s" stroke="#555" stroke-dasharray="2 3"/>`+"\n", x, coord(top), x, coord(bottom))
+		printf(`<text x="%s" y="%s" fill="#555" dx="4" dy="12">%s</text>`+"\n", x, coord(top), escape(marker.Name))
+	}
+
+	// Series
+
+	for i, series := range c.Series {
+		color := palette[i%len(palette)]
+
+		points := make([]string, 0, len(series.Points))
+		for _, point := range series.Points {
+			points = append(points, coord(xScale.position(point.X))+","+coord(yScale.position(point.Y)))
+		}
+		printf(`<polyline class="series" fill="none" stroke="%s" stroke-width="2" points="%s"/>`+"\n", color, strings.Join(points, " "))
+
+		if len(c.Series) > 1 || series.Name != "" {
+			y := top + 16 + float64(i)*16
+			printf(`<rect x="%s" y="%s" width="10" height="10" fill="%s"/>`+"\n", coord(left+10), coord(y-9), color)
+			printf(`<text x="%s" y="%s">%s</text>`+"\n", coord(left+24), coord(y), escape(series.Name))
+		}
+	}
+
+	printf("</svg>\n")
+
+	return bw.Flush()
+}
+
+// String returns the SVG document or an empty string if the chart can't be drawn.
+func (c Chart) String() string {
+	var sb strings.Builder
+	if err := c.WriteSVG(&sb); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+var palette = []string{
+	"#2471a3",
+	"#d68910",
+	"#229954",
+	"#7d3c98",
+	"#a04000",
+	"#17a589",
+}
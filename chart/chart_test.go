@@ -0,0 +1,91 @@
+package chart
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireValidXML(t *testing.T, data string) {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	c := Chart{
+		Title: `Partition size of "events" & more`,
+		X:     Axis{Label: "Rows", Log: true},
+		Y:     Axis{Label: "Bytes", Log: true, Format: func(v float64) string { return "<" + strings.Repeat("=", int(v)%3) + ">" }},
+		Series: []Series{
+			{Name: "events", Points: []Point{{1, 100}, {10, 1000}, {100, 10000}}},
+			{Name: "users", Points: []Point{{1, 50}, {10, 500}, {100, 5000}}},
+		},
+		Thresholds: []Line{{Name: "100 KiB", Value: 100 * 1024}},
+		Markers:    []Line{{Name: "Estimated", Value: 20}},
+	}
+
+	var sb strings.Builder
+	require.NoError(t, c.WriteSVG(&sb))
+
+	svg := sb.String()
+	requireValidXML(t, svg)
+
+	require.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	require.Contains(t, svg, "<title>Partition size of &#34;events&#34; &amp; more</title>")
+	require.Equal(t, 2, strings.Count(svg, `class="series"`))
+	require.Equal(t, 1, strings.Count(svg, `class="threshold"`))
+	require.Equal(t, 1, strings.Count(svg, `class="marker"`))
+	require.Contains(t, svg, ">events</text>")
+
+	require.Equal(t, svg, c.String())
+}
+
+func TestWriteSVGNoData(t *testing.T) {
+	var c Chart
+	require.ErrorIs(t, c.WriteSVG(io.Discard), ErrNoData)
+	require.Equal(t, "", c.String())
+}
+
+func TestScale(t *testing.T) {
+	logScale := newScale(Axis{Log: true}, []float64{3, 2000, 0}, 0, 100)
+	require.Equal(t, 0.0, logScale.min)
+	require.Equal(t, 4.0, logScale.max)
+	require.Equal(t, []float64{1, 10, 100, 1000, 10000}, logScale.ticks())
+	require.Equal(t, 0.0, logScale.position(1))
+	require.Equal(t, 50.0, logScale.position(100))
+	require.Equal(t, 100.0, logScale.position(10000))
+
+	linearScale := newScale(Axis{}, []float64{10, 95}, 100, 0)
+	require.Equal(t, 0.0, linearScale.min)
+	require.Equal(t, []float64{0, 20, 40, 60, 80}, linearScale.ticks())
+	require.Equal(t, 100.0, linearScale.position(0))
+	require.Equal(t, 0.0, linearScale.position(95))
+}
+
+func TestNiceStep(t *testing.T) {
+	testCases := []struct {
+		step float64
+		exp  float64
+	}{
+		{0, 1},
+		{0.3, 0.5},
+		{1, 1},
+		{1.5, 2},
+		{19, 20},
+		{42, 50},
+		{700, 1000},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.exp, niceStep(tc.step), "step %v", tc.step)
+	}
+}
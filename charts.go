@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+	"rischmann.fr/cassandra-partition-calculator/chart"
+	"rischmann.fr/cassandra-partition-calculator/cql"
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+)
+
+type chartMetric string
+
+const (
+	chartMetricBytes  chartMetric = "bytes"
+	chartMetricValues chartMetric = "values"
+)
+
+// maxChartRowSamplesLimit is the largest sample of chartRowSamples, 5 times it doesn't overflow an int64.
+const maxChartRowSamplesLimit = math.MaxInt64 / 10
+
+// chartRowSamples returns the number of rows at which the partition is estimated:
+// 1, 2 and 5 times every power of 10 up to 10 times rows, plus rows itself.
+// The samples are capped at maxChartRowSamplesLimit for the largest numbers of rows.
+func chartRowSamples(rows int64) []int64 {
+	limit := int64(maxChartRowSamplesLimit)
+	if l := math.Pow(10, math.Ceil(math.Log10(float64(max(rows, 1))))+1); l < maxChartRowSamplesLimit {
+		limit = int64(l)
+	}
+
+	res := []int64{rows}
+	for magnitude := int64(1); magnitude <= limit; magnitude *= 10 {
+		for _, factor := range []int64{1, 2, 5} {
+			if n := factor * magnitude; n <= limit && n != rows {
+				res = append(res, n)
+			}
+		}
+
+		// The next magnitude would overflow
+		if magnitude > limit/10 {
+			break
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+
+	return res
+}
+
+func formatChartCount(v float64) string {
+	return strings.ReplaceAll(humanize.SIWithDigits(v, 0, ""), " ", "")
+}
+
+// chartTable is a table of a chart with its estimated number of rows.
+type chartTable struct {
+	schema cql.Schema
+	rows   int64
+}
+
+// chartTables returns the tables of a chart with the same estimated number of rows.
+func chartTables(schemas []cql.Schema, rows int64) []chartTable {
+	res := make([]chartTable, 0, len(schemas))
+	for _, schema := range schemas {
+		res = append(res, chartTable{schema: schema, rows: rows})
+	}
+	return res
+}
+
+// partitionChart returns the chart of the metric of the partitions of the tables as the number of rows grows.
+// The estimated numbers of rows of the tables are marked on the chart, the sizes are formatted with formatter
+// and the labels are translated in the language of ctx.
+func partitionChart(ctx context.Context, formatter format.Formatter, thresholds cassandra.Thresholds, metric chartMetric, tables []chartTable) (chart.Chart, error) {
+	res := chart.Chart{
+		X: chart.Axis{
+			Label:  i18n.T(ctx, "Rows"),
+			Log:    true,
+			Format: formatChartCount,
+		},
+		Y: chart.Axis{
+			Log: true,
+		},
+	}
+
+	markers := make(map[int64]struct{}, len(tables))
+	for _, table := range tables {
+		if _, ok := markers[table.rows]; ok {
+			continue
+		}
+		markers[table.rows] = struct{}{}
+
+		res.Markers = append(res.Markers, chart.Line{
+			Name:  i18n.T(ctx, "%s rows", formatChartCount(float64(table.rows))),
+			Value: float64(table.rows),
+		})
+	}
+	sort.Slice(res.Markers, func(i, j int) bool { return res.Markers[i].Value < res.Markers[j].Value })
+
+	switch metric {
+	case chartMetricBytes:
+		res.Title = i18n.T(ctx, "Partition size")
+		res.Y.Label = i18n.T(ctx, "Size")
+		res.Y.Format = func(v float64) string {
+			return formatter.Bytes(int64(v))
+		}
+		res.Thresholds = []chart.Line{
+			{Name: i18n.T(ctx, "Recommended maximum"), Value: float64(thresholds.MaxPartitionBytes)},
+		}
+
+	case chartMetricValues:
+		res.Title = i18n.T(ctx, "Partition values")
+		res.Y.Label = i18n.T(ctx, "Values")
+		res.Y.Format = formatChartCount
+		res.Thresholds = []chart.Line{
+			{Name: i18n.T(ctx, "Recommended maximum"), Value: float64(thresholds.MaxPartitionValues)},
+			{Name: i18n.T(ctx, "Hard limit"), Value: cassandra.MaxPartitionValues},
+		}
+
+	default:
+		return res, fmt.Errorf("invalid chart metric %q", metric)
+	}
+
+	for _, table := range tables {
+		series := chart.Series{
+			Name: table.schema.QualifiedName(),
+		}
+
+		for _, n := range chartRowSamples(table.rows) {
+			estimation, err := cassandra.Estimate(table.schema, n)
+			if err != nil {
+				return res, err
+			}
+
+			point := chart.Point{X: float64(n), Y: float64(estimation.Values)}
+			if metric == chartMetricBytes {
+				point.Y = float64(estimation.Bytes)
+			}
+			series.Points = append(series.Points, point)
+		}
+
+		res.Series = append(res.Series, series)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+	"rischmann.fr/cassandra-partition-calculator/chart"
+	"rischmann.fr/cassandra-partition-calculator/cql"
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+)
+
+func TestChartRowSamples(t *testing.T) {
+	require.Equal(t, []int64{1, 2, 5, 10, 20, 50, 100}, chartRowSamples(10))
+	require.Equal(t, []int64{1, 2, 5, 10, 20, 42, 50, 100, 200, 500, 1000}, chartRowSamples(42))
+	require.Equal(t, []int64{0, 1, 2, 5, 10}, chartRowSamples(0))
+
+	// The samples don't overflow with large numbers of rows
+	for _, rows := range []int64{1e17, 1e18, math.MaxInt64} {
+		samples := chartRowSamples(rows)
+		require.Greater(t, len(samples), 50, "rows %d", rows)
+		require.Equal(t, int64(1), samples[0], "rows %d", rows)
+		require.Contains(t, samples, rows)
+		for i := 1; i < len(samples); i++ {
+			require.Greater(t, samples[i], samples[i-1], "rows %d", rows)
+		}
+	}
+	samples := chartRowSamples(1e16)
+	require.Equal(t, int64(1e17), samples[len(samples)-1])
+}
+
+func TestPartitionChart(t *testing.T) {
+	schema, err := cql.ParseSchema("CREATE TABLE events(user_id uuid, event_id timeuuid, data blob, PRIMARY KEY (user_id, event_id));")
+	require.NoError(t, err)
+
+	formatter := format.New(message.NewPrinter(language.English), format.IEC)
+
+	bytesChart, err := partitionChart(context.Background(), formatter, cassandra.DefaultThresholds(), chartMetricBytes, chartTables([]cql.Schema{schema}, 1000))
+	require.NoError(t, err)
+	require.Len(t, bytesChart.Series, 1)
+	require.Equal(t, "events", bytesChart.Series[0].Name)
+	require.Len(t, bytesChart.Thresholds, 1)
+	require.Equal(t, "1.0 MiB", bytesChart.Y.Format(1<<20))
+
+	// The size grows with the number of rows
+	points := bytesChart.Series[0].Points
+	for i := 1; i < len(points); i++ {
+		require.Greater(t, points[i].X, points[i-1].X)
+		require.Greater(t, points[i].Y, points[i-1].Y)
+	}
+
+	valuesChart, err := partitionChart(context.Background(), formatter, cassandra.DefaultThresholds(), chartMetricValues, chartTables([]cql.Schema{schema, schema}, 1000))
+	require.NoError(t, err)
+	require.Len(t, valuesChart.Series, 2)
+	require.Len(t, valuesChart.Thresholds, 2)
+	require.Len(t, valuesChart.Markers, 1)
+	require.Contains(t, valuesChart.String(), "Hard limit")
+
+	// Every table is sampled around its own number of rows, which is marked
+	tablesChart, err := partitionChart(context.Background(), formatter, cassandra.DefaultThresholds(), chartMetricBytes, []chartTable{
+		{schema: schema, rows: 1000},
+		{schema: schema, rows: 42},
+	})
+	require.NoError(t, err)
+	require.Len(t, tablesChart.Series, 2)
+	require.Equal(t, []float64{42, 1000}, []float64{tablesChart.Markers[0].Value, tablesChart.Markers[1].Value})
+	require.Equal(t, float64(1e4), tablesChart.Series[0].Points[len(tablesChart.Series[0].Points)-1].X)
+	require.Equal(t, float64(1e3), tablesChart.Series[1].Points[len(tablesChart.Series[1].Points)-1].X)
+	require.Contains(t, pointsX(tablesChart.Series[1].Points), float64(42))
+
+	// The sizes are formatted with the units of the user
+	siChart, err := partitionChart(context.Background(), format.New(message.NewPrinter(language.English), format.SI), cassandra.DefaultThresholds(), chartMetricBytes, chartTables([]cql.Schema{schema}, 1000))
+	require.NoError(t, err)
+	require.Equal(t, "1.0 MB", siChart.Y.Format(1e6))
+
+	// The thresholds are configurable
+	thresholdsChart, err := partitionChart(context.Background(), formatter, cassandra.Thresholds{MaxPartitionBytes: 1 << 20, MaxPartitionValues: 1000}, chartMetricValues, chartTables([]cql.Schema{schema}, 1000))
+	require.NoError(t, err)
+	require.Equal(t, float64(1000), thresholdsChart.Thresholds[0].Value)
+
+	// The labels are translated
+	frenchChart, err := partitionChart(i18n.WithLanguage(context.Background(), language.French), formatter, cassandra.DefaultThresholds(), chartMetricValues, chartTables([]cql.Schema{schema}, 1000))
+	require.NoError(t, err)
+	require.Equal(t, "Valeurs de la partition", frenchChart.Title)
+	require.Equal(t, "Lignes", frenchChart.X.Label)
+	require.Equal(t, "Maximum recommandé", frenchChart.Thresholds[0].Name)
+	require.Equal(t, "1k lignes", frenchChart.Markers[0].Name)
+
+	_, err = partitionChart(context.Background(), formatter, cassandra.DefaultThresholds(), "foo", chartTables([]cql.Schema{schema}, 1000))
+	require.Error(t, err)
+}
+
+func TestEvaluateHandlerCharts(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	form := url.Values{
+		"schema": {"CREATE TABLE events(user_id uuid PRIMARY KEY, name text);"},
+		"rows":   {"10"},
+	}
+
+	rec := doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 2, strings.Count(rec.Body.String(), "<svg"))
+}
+
+func pointsX(points []chart.Point) []float64 {
+	res := make([]float64, 0, len(points))
+	for _, point := range points {
+		res = append(res, point.X)
+	}
+	return res
+}
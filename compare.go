@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+	"rischmann.fr/cassandra-partition-calculator/lint"
+	"rischmann.fr/cassandra-partition-calculator/scenario"
+	"rischmann.fr/cassandra-partition-calculator/ui"
+)
+
+var (
+	errScenariosDisabled = i18n.NewError("saved scenarios are disabled")
+	errScenarioNotFound  = i18n.NewError("scenario not found")
+	errCompareKeyspace   = i18n.NewError("schemas with several tables can't be compared, open the link to evaluate the tables")
+)
+
+// compareEntry is one calculation to compare.
+type compareEntry struct {
+	label string
+	form  url.Values
+	err   error
+}
+
+// parseSizeEstimatesList parses size estimates written as "column=bytes, column=bytes".
+func parseSizeEstimatesList(data string) (map[string]int, error) {
+	var res map[string]int
+
+	for _, part := range strings.Split(data, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, i18n.NewError("invalid size estimate %q, expected column=bytes", part)
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, i18n.NewError("invalid size estimate %q, err: %s", part, err)
+		}
+
+		if res == nil {
+			res = make(map[string]int)
+		}
+		res[strings.ToLower(strings.TrimSpace(name))] = size
+	}
+
+	return res, nil
+}
+
+// formatSizeEstimatesList is the inverse of parseSizeEstimatesList.
+func formatSizeEstimatesList(sizeEstimates map[string]int) string {
+	parts := make([]string, 0, len(sizeEstimates))
+	for name, size := range sizeEstimates {
+		parts = append(parts, name+"="+strconv.Itoa(size))
+	}
+	sort.Strings(parts)
+
+	return strings.Join(parts, ", ")
+}
+
+// compareEntries returns the calculations to compare from the query:
+// * the saved scenarios in the "scenario" parameters
+// * the permalinks states in the "state" parameters
+// * the "schema", "rows" and "sizes" parameters of the compare form, matched by position
+func (c *serveCommandConfig) compareEntries(ctx context.Context, query url.Values) []compareEntry {
+	var res []compareEntry
+
+	for _, idStr := range query["scenario"] {
+		entry := compareEntry{label: i18n.T(ctx, "Scenario %s", idStr)}
+
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		switch {
+		case err != nil:
+			entry.err = i18n.NewError("invalid scenario id %q", idStr)
+		case c.scenarios == nil:
+			entry.err = errScenariosDisabled
+		default:
+			s, err := c.scenarios.Get(id)
+			switch {
+			case errors.Is(err, scenario.ErrNotFound):
+				entry.err = errScenarioNotFound
+			case err != nil:
+				entry.err = err
+			default:
+				entry.label = s.Name
+				entry.form = scenarioForm(s)
+			}
+		}
+
+		res = append(res, entry)
+	}
+
+	for _, encoded := range query["state"] {
+		var entry compareEntry
+
+		state, err := decodePermalinkState(encoded)
+		if err != nil {
+			entry.err = err
+		} else {
+			entry.form = state.Form()
+		}
+
+		res = append(res, entry)
+	}
+
+	at := func(values []string, i int) string {
+		if i < len(values) {
+			return values[i]
+		}
+		return ""
+	}
+
+	for i, schema := range query["schema"] {
+		if strings.TrimSpace(schema) == "" {
+			continue
+		}
+
+		entry := compareEntry{
+			form: url.Values{
+				"schema": {schema},
+				"rows":   {at(query["rows"], i)},
+			},
+		}
+
+		sizeEstimates, err := parseSizeEstimatesList(at(query["sizes"], i))
+		if err != nil {
+			entry.err = &validationError{field: "sizes", err: err}
+		}
+		for name, size := range sizeEstimates {
+			entry.form.Set("size::"+name, strconv.Itoa(size))
+		}
+
+		res = append(res, entry)
+	}
+
+	for i := range res {
+		if res[i].label == "" {
+			res[i].label = i18n.T(ctx, "Design %d", i+1)
+		}
+	}
+
+	return res
+}
+
+var compareMetricNames = []string{
+	"Rows",
+	"Values",
+	"Partition size",
+	"Partition key",
+	"Clustering key",
+	"Metadata",
+	"Rows data",
+}
+
+// compareMetrics returns the values of compareMetricNames.
+func compareMetrics(rows int64, estimation cassandra.Estimation) []int64 {
+	return []int64{
+		rows,
+		int64(estimation.Values),
+		int64(estimation.Bytes),
+		int64(estimation.PartitionKeyBytes),
+		int64(estimation.ClusteringKeyBytes),
+		int64(estimation.MetadataBytes),
+		int64(estimation.RowsBytes),
+	}
+}
+
+// formatCompareMetric formats the metric at index i of compareMetricNames.
+// The first two metrics are counts, the others are sizes in bytes.
+func formatCompareMetric(formatter format.Formatter, i int, n int64) string {
+	if i < 2 {
+		return formatter.Number(n)
+	}
+	return formatter.Bytes(n)
+}
+
+func newCompareMetric(formatter format.Formatter, i int, value int64, baseline []int64) ui.CompareMetric {
+	res := ui.CompareMetric{
+		Value: formatCompareMetric(formatter, i, value),
+	}
+	if baseline == nil {
+		return res
+	}
+
+	diff := value - baseline[i]
+	switch {
+	case diff > 0:
+		res.Change = 1
+		res.Delta = "+" + formatCompareMetric(formatter, i, diff)
+	case diff < 0:
+		res.Change = -1
+		res.Delta = "-" + formatCompareMetric(formatter, i, -diff)
+	default:
+		res.Delta = "="
+		return res
+	}
+
+	if baseline[i] != 0 {
+		res.Delta += fmt.Sprintf(" (%+.1f%%)", float64(diff)*100/float64(baseline[i]))
+	}
+
+	return res
+}
+
+func (c *serveCommandConfig) compareHandler(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+
+	query := req.URL.Query()
+	entries := c.compareEntries(req.Context(), query)
+
+	// Invalid units are ignored, the select of the form only has valid ones
+	units, err := format.ParseUnits(query.Get(unitsParam))
+	if err != nil {
+		c.logger(req.Context()).Debug("invalid units", zap.Error(err))
+	}
+	formatter := newFormatter(req.Context(), units)
+
+	// Fill the form with the calculations, plus an empty one to add a new design
+
+	data := ui.CompareData{
+		Units: units.String(),
+	}
+	for _, entry := range entries {
+		if entry.form == nil {
+			continue
+		}
+
+		data.Inputs = append(data.Inputs, ui.CompareInput{
+			Schema:        entry.form.Get("schema"),
+			Rows:          entry.form.Get("rows"),
+			SizeEstimates: formatSizeEstimatesList(sizeEstimatesFromForm(entry.form)),
+		})
+	}
+	if len(data.Inputs) == 0 {
+		data.Inputs = append(data.Inputs, ui.CompareInput{Schema: c.defaultExample().Schema, Rows: defaultRows})
+	}
+	data.Inputs = append(data.Inputs, ui.CompareInput{Rows: defaultRows})
+
+	// Estimate every calculation, the first valid one is the baseline of the deltas
+
+	var baseline []int64
+	for _, entry := range entries {
+		data.Columns = append(data.Columns, c.compareColumn(req.Context(), formatter, entry, &baseline))
+	}
+	if len(data.Columns) > 0 {
+		data.MetricNames = compareMetricNames
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	baseURL := c.baseURLOf(req.Context())
+	page := ui.MainPage(baseURL, pageTitle, c.languageLinks(req), ui.CompareComponent(baseURL, data))
+	page.Render(req.Context(), w)
+}
+
+// compareColumn estimates the calculation of entry.
+// baseline is set to the metrics of the calculation if it's nil.
+func (c *serveCommandConfig) compareColumn(ctx context.Context, formatter format.Formatter, entry compareEntry, baseline *[]int64) ui.CompareColumn {
+	res := ui.CompareColumn{
+		Label: entry.label,
+	}
+
+	err := entry.err
+
+	// The metrics are the ones of a single table, the link keeps the numbers of rows of every table
+	if err == nil && c.isKeyspaceForm(entry.form) {
+		res.Permalink, err = c.permalinkURL(ctx, newPermalinkState(entry.form))
+		if err != nil {
+			c.logger(ctx).Error("unable to create permalink", zap.Error(err))
+		}
+
+		res.ErrorMessages = errorMessages(ctx, errCompareKeyspace)
+		return res
+	}
+
+	var calculation evaluationSchema
+	if err == nil {
+		calculation, err = c.parseEvaluateForm(entry.form)
+	}
+
+	var estimation cassandra.Estimation
+	if err == nil {
+		estimation, err = c.metrics.estimate(calculation.schema, calculation.rows)
+	}
+
+	if err != nil {
+		c.logger(ctx).Error("unable to evaluate compared calculation", zap.String("label", entry.label), zap.Error(err))
+
+		res.ErrorMessages = errorMessages(ctx, err)
+		return res
+	}
+
+	metrics := compareMetrics(calculation.rows, estimation)
+	for i, value := range metrics {
+		res.Metrics = append(res.Metrics, newCompareMetric(formatter, i, value, *baseline))
+	}
+	if *baseline == nil {
+		*baseline = metrics
+	}
+
+	res.Findings = lint.Run(c.lintConfig, calculation.schema)
+
+	res.Permalink, err = c.permalinkURL(ctx, newPermalinkState(entry.form))
+	if err != nil {
+		c.logger(ctx).Error("unable to create permalink", zap.Error(err))
+	}
+
+	return res
+}
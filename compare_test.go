@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/scenario"
+	"rischmann.fr/cassandra-partition-calculator/ui"
+)
+
+func TestParseSizeEstimatesList(t *testing.T) {
+	res, err := parseSizeEstimatesList(" Event_Data=100, name = 20,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"event_data": 100, "name": 20}, res)
+	require.Equal(t, "event_data=100, name=20", formatSizeEstimatesList(res))
+
+	res, err = parseSizeEstimatesList("")
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	_, err = parseSizeEstimatesList("event_data")
+	require.Error(t, err)
+
+	_, err = parseSizeEstimatesList("event_data=foo")
+	require.Error(t, err)
+}
+
+func TestNewCompareMetric(t *testing.T) {
+	baseline := compareMetrics(100, cassandra.Estimation{Values: 200, Bytes: 2048})
+	english := format.New(message.NewPrinter(language.English), format.IEC)
+
+	require.Equal(t, ui.CompareMetric{Value: "100"}, newCompareMetric(english, 0, 100, nil))
+	require.Equal(t, ui.CompareMetric{Value: "100", Delta: "="}, newCompareMetric(english, 0, 100, baseline))
+	require.Equal(t, ui.CompareMetric{Value: "150", Delta: "+50 (+50.0%)", Change: 1}, newCompareMetric(english, 0, 150, baseline))
+	require.Equal(t, ui.CompareMetric{Value: "1.0 KiB", Delta: "-1.0 KiB (-50.0%)", Change: -1}, newCompareMetric(english, 2, 1024, baseline))
+
+	si := format.New(message.NewPrinter(language.English), format.SI)
+	require.Equal(t, ui.CompareMetric{Value: "1.0 kB", Delta: "-1.0 kB (-51.2%)", Change: -1}, newCompareMetric(si, 2, 1000, baseline))
+}
+
+func TestCompareHandler(t *testing.T) {
+	c := newTestScenariosServeCommandConfig(t)
+	handler := c.routes()
+
+	saved, err := c.scenarios.Save(scenario.Scenario{
+		Name:   "by user",
+		Schema: "CREATE TABLE events(user_id uuid, event_id timeuuid, PRIMARY KEY (user_id, event_id));",
+		Rows:   1000,
+	})
+	require.NoError(t, err)
+
+	state, err := permalinkState{
+		Schema: "CREATE TABLE events(user_id uuid, day date, event_id timeuuid, PRIMARY KEY ((user_id, day), event_id));",
+		Rows:   "100",
+	}.Encode()
+	require.NoError(t, err)
+
+	query := url.Values{
+		"scenario": {"1", "10"},
+		"state":    {state},
+		"schema":   {"CREATE TABLE flags(enabled boolean PRIMARY KEY, data blob);", ""},
+		"rows":     {"1", "100000"},
+		"sizes":    {"data=10", ""},
+	}
+
+	rec := doRequest(t, handler, http.MethodGet, "/compare?"+query.Encode(), "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+
+	// Every calculation has a column
+	require.Contains(t, body, saved.Name)
+	require.Contains(t, body, "Scenario 10")
+	require.Contains(t, body, "Design 3")
+	require.Contains(t, body, "Design 4")
+	require.Contains(t, body, scenario.ErrNotFound.Error())
+
+	// Deltas are computed against the first calculation
+	require.Contains(t, body, `class="delta delta-decrease"`)
+	require.Contains(t, body, "(-90.0%)")
+
+	// Lint findings are displayed
+	require.Contains(t, body, "low-cardinality-partition-key")
+
+	// The form contains the calculations plus an empty one
+	require.Equal(t, 4, strings.Count(body, `name="schema"`))
+	require.Contains(t, body, `value="data=10"`)
+}
+
+func TestCompareHandlerKeyspace(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	state, err := permalinkState{
+		Schema:    testKeyspaceSchema,
+		Rows:      "100",
+		TableRows: map[string]int64{"ks.events": 1000},
+	}.Encode()
+	require.NoError(t, err)
+
+	query := url.Values{
+		"state":  {state},
+		"schema": {"CREATE TABLE flags(enabled boolean PRIMARY KEY);"},
+		"rows":   {"1"},
+	}
+
+	rec := doRequest(t, c.routes(), http.MethodGet, "/compare?"+query.Encode(), "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// The keyspace isn't compared on its first table only, its link keeps the rows of every table
+	body := rec.Body.String()
+	require.Contains(t, body, "schemas with several tables can&#39;t be compared")
+	require.Contains(t, body, ">Design 1</a>")
+	require.Contains(t, body, ">Design 2</a>")
+}
+
+func TestCompareHandlerUnits(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	query := url.Values{
+		"schema": {"CREATE TABLE events(user_id uuid, event_id timeuuid, PRIMARY KEY (user_id, event_id));"},
+		"rows":   {"100000"},
+		"units":  {"mib"},
+	}
+
+	rec := doRequest(t, c.routes(), http.MethodGet, "/compare?"+query.Encode(), "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, "2.29 MiB")
+	require.Contains(t, body, `<option value="MiB" selected>`)
+}
+
+func TestCompareHandlerEmpty(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	rec := doRequest(t, c.routes(), http.MethodGet, "/compare", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Equal(t, 2, strings.Count(body, `name="schema"`))
+	require.NotContains(t, body, `id="comparison"`)
+}
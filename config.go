@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/peterbourgon/ff/v3"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+)
+
+// envVarPrefix is the prefix of the environment variables setting the flags.
+// For example the flag -listen-addr of the serve command is set by CASSANDRA_PARTITION_CALCULATOR_LISTEN_ADDR.
+const envVarPrefix = "CASSANDRA_PARTITION_CALCULATOR"
+
+// options are the options of the root command: its flags can be set with environment variables and the config file.
+//
+// The config file contains one flag per line, for example:
+//
+//	listen-addr :8080
+//	examples-dir /etc/cassandra-partition-calculator/examples
+//
+// It can contain the flags of the root command and of the subcommands using it.
+func (c *rootCommandConfig) options() []ff.Option {
+	return []ff.Option{
+		ff.WithEnvVarPrefix(envVarPrefix),
+		ff.WithConfigFileFlag("config"),
+		ff.WithConfigFileParser(ff.PlainParser),
+		ff.WithIgnoreUndefined(true),
+	}
+}
+
+// subcommandOptions are the options of the subcommands configured like the root command,
+// with environment variables and the config file given to the root command.
+func (c *rootCommandConfig) subcommandOptions() []ff.Option {
+	return []ff.Option{
+		ff.WithEnvVarPrefix(envVarPrefix),
+		ff.WithConfigFileVia(&c.configPath),
+		ff.WithConfigFileParser(ff.PlainParser),
+		ff.WithIgnoreUndefined(true),
+	}
+}
+
+// registerThresholdFlags registers the flags configuring the thresholds of the partitions on fs.
+func registerThresholdFlags(fs *flag.FlagSet, thresholds *cassandra.Thresholds) {
+	fs.Int64Var(&thresholds.MaxPartitionBytes, "max-partition-size", thresholds.MaxPartitionBytes, "Size in bytes above which a partition is considered too large")
+	fs.Int64Var(&thresholds.MaxPartitionValues, "max-partition-values", thresholds.MaxPartitionValues, "Number of values above which a partition is considered too large")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/stretchr/testify/require"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+)
+
+func parseServeCommand(t *testing.T, args ...string) *serveCommandConfig {
+	t.Helper()
+
+	rootCfg, rootCmd := newRootCommand()
+	serveCfg, serveCmd := newServeCommandConfig(rootCfg)
+	rootCmd.Subcommands = []*ffcli.Command{serveCmd}
+
+	require.NoError(t, rootCmd.Parse(args))
+
+	return serveCfg
+}
+
+func TestServeCommandConfiguration(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(configPath, []byte(`
+# Deployment configuration
+listen-addr :9000
+base-url https://example.com/calculator
+examples-dir /etc/examples
+examples-dir /srv/examples
+lint-max-columns 20
+max-partition-size 52428800
+`), 0o644)
+	require.NoError(t, err)
+
+	t.Run("defaults", func(t *testing.T) {
+		c := parseServeCommand(t, "serve")
+
+		require.Equal(t, ":8909", c.listenAddr)
+		require.Equal(t, "", c.baseURL)
+		require.Empty(t, c.examplesDirs)
+	})
+
+	t.Run("config-file", func(t *testing.T) {
+		c := parseServeCommand(t, "-config", configPath, "serve")
+
+		require.Equal(t, ":9000", c.listenAddr)
+		require.Equal(t, "https://example.com/calculator", c.baseURL)
+		require.Equal(t, []string{"/etc/examples", "/srv/examples"}, c.examplesDirs)
+		require.Equal(t, 20, c.lintConfig.MaxColumns)
+		require.Equal(t, int64(52428800), c.thresholds.MaxPartitionBytes)
+		require.Equal(t, int64(cassandra.RecommendedMaxPartitionValues), c.thresholds.MaxPartitionValues)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("CASSANDRA_PARTITION_CALCULATOR_CONFIG", configPath)
+		t.Setenv("CASSANDRA_PARTITION_CALCULATOR_LISTEN_ADDR", ":9100")
+		t.Setenv("CASSANDRA_PARTITION_CALCULATOR_EXAMPLES_DIR", "/opt/examples,/opt/more-examples")
+		t.Setenv("CASSANDRA_PARTITION_CALCULATOR_MAX_PARTITION_VALUES", "50000")
+
+		c := parseServeCommand(t, "serve")
+
+		// The environment variables have priority over the config file
+		require.Equal(t, ":9100", c.listenAddr)
+		require.Equal(t, "https://example.com/calculator", c.baseURL)
+		require.Equal(t, []string{"/opt/examples", "/opt/more-examples"}, c.examplesDirs)
+		require.Equal(t, 20, c.lintConfig.MaxColumns)
+		require.Equal(t, int64(52428800), c.thresholds.MaxPartitionBytes)
+		require.Equal(t, int64(50000), c.thresholds.MaxPartitionValues)
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		t.Setenv("CASSANDRA_PARTITION_CALCULATOR_LISTEN_ADDR", ":9100")
+
+		c := parseServeCommand(t, "-config", configPath, "serve", "-listen-addr", ":9200", "-base-url", "http://localhost:9200")
+
+		// The flags have priority over everything else
+		require.Equal(t, ":9200", c.listenAddr)
+		require.Equal(t, "http://localhost:9200", c.baseURL)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("CASSANDRA_PARTITION_CALCULATOR_LISTEN_ADDR", "foobar")
+
+		rootCfg, rootCmd := newRootCommand()
+		_, serveCmd := newServeCommandConfig(rootCfg)
+		rootCmd.Subcommands = []*ffcli.Command{serveCmd}
+
+		err := rootCmd.Parse([]string{"serve"})
+		require.ErrorContains(t, err, "CASSANDRA_PARTITION_CALCULATOR_LISTEN_ADDR")
+	})
+}
@@ -0,0 +1,181 @@
+package cql
+
+import (
+	"errors"
+	"strings"
+)
+
+// UserType is a user defined type created with CREATE TYPE.
+type UserType struct {
+	Pos      Position
+	Comments []string
+	Keyspace string
+	Name     string
+	Fields   ColumnDefinitions
+}
+
+// QualifiedName returns the type name prefixed by its keyspace if there's one.
+func (t UserType) QualifiedName() string {
+	if t.Keyspace == "" {
+		return t.Name
+	}
+	return t.Keyspace + "." + t.Name
+}
+
+func (p *parser) parseCreateType() (res UserType, err error) {
+	res.Pos = p.nextPos()
+	res.Comments = p.lexer.TakeComments()
+
+	if err = parseStrings(p, "CREATE", "TYPE"); err != nil {
+		return
+	}
+
+	parseOptionalStrings(p, "IF", "NOT", "EXISTS")
+
+	var name string
+	if err = parseNextStringInto(p, &name); err != nil {
+		return
+	}
+	res.Keyspace, res.Name = splitQualifiedName(name)
+
+	if err = parseStrings(p, "("); err != nil {
+		return
+	}
+
+	for {
+		pos := p.nextPos()
+		comments := p.lexer.TakeComments()
+
+		field := ColumnDefinition{
+			Pos:      pos,
+			Comments: comments,
+		}
+		if err = parseNextStringInto(p, &field.Name); err != nil {
+			return
+		}
+		if field.Type, err = p.parseTypeDefinition(); err != nil {
+			return
+		}
+
+		res.Fields = append(res.Fields, field)
+
+		if !parseOptionalStrings(p, ",") {
+			break
+		}
+	}
+
+	err = parseStrings(p, ")")
+
+	return
+}
+
+// skipStatement skips all tokens up to and including the next ; outside of any brackets.
+func (p *parser) skipStatement() error {
+	level := 0
+
+	for {
+		tok, err := p.lexer.Next()
+		if errors.Is(err, errEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case "(", "{", "[":
+			level++
+		case ")", "}", "]":
+			level--
+		case ";":
+			if level <= 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// peekStatement returns the first two keywords of the next statement, upper cased.
+func (p *parser) peekStatement() (string, string) {
+	p.lexer.ResetUndo()
+	defer p.lexer.UndoAll()
+
+	first, err := p.lexer.Next()
+	if err != nil {
+		return "", ""
+	}
+	second, err := p.lexer.Next()
+	if err != nil {
+		return strings.ToUpper(first.String()), ""
+	}
+
+	return strings.ToUpper(first.String()), strings.ToUpper(second.String())
+}
+
+// ParseDescribe parses the output of a cqlsh DESCRIBE KEYSPACE or DESCRIBE SCHEMA command.
+//
+// Tables and user defined types are returned; all other statements (keyspaces, indexes, views, functions...) are skipped.
+func ParseDescribe(data string) (tables []Schema, types []UserType, err error) {
+	parser := &parser{
+		lexer: newLexer(data),
+	}
+
+	for parser.lexer.eatWhitespace() == nil {
+		switch first, second := parser.peekStatement(); {
+		case first == "CREATE" && second == "TABLE":
+			var schema Schema
+			if schema, err = parser.parse(); err != nil {
+				return nil, nil, parser.syntaxError(err)
+			}
+			tables = append(tables, schema)
+
+			parseOptionalStrings(parser, ";")
+
+		case first == "CREATE" && second == "TYPE":
+			var typ UserType
+			if typ, err = parser.parseCreateType(); err != nil {
+				return nil, nil, parser.syntaxError(err)
+			}
+			types = append(types, typ)
+
+			parseOptionalStrings(parser, ";")
+
+		default:
+			// Comments attached to a skipped statement are dropped with it
+			parser.lexer.TakeComments()
+
+			if err = parser.skipStatement(); err != nil {
+				return nil, nil, parser.syntaxError(err)
+			}
+		}
+	}
+
+	return tables, types, nil
+}
+
+// FormatUserType renders the user defined type as canonical CQL.
+func FormatUserType(typ UserType) string {
+	var sb strings.Builder
+
+	writeComments(&sb, "", typ.Comments)
+
+	sb.WriteString("CREATE TYPE ")
+	sb.WriteString(typ.QualifiedName())
+	sb.WriteString(" (\n")
+
+	for i, field := range typ.Fields {
+		if i > 0 {
+			sb.WriteString(",\n")
+		}
+
+		writeComments(&sb, "\t", field.Comments)
+		sb.WriteString("\t")
+		sb.WriteString(field.Name)
+		sb.WriteString(" ")
+		sb.WriteString(formatTypeName(field.Type.Name))
+	}
+
+	sb.WriteString("\n);\n")
+
+	return sb.String()
+}
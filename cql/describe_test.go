@@ -0,0 +1,113 @@
+package cql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const describeKeyspaceOutput = `CREATE KEYSPACE tracking WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': '3'}  AND durable_writes = true;
+
+CREATE TYPE tracking.address (
+    street text,
+    city text
+);
+
+-- Events sent by the clients
+CREATE TABLE tracking.events (
+    tenant_key bigint,
+    user_id uuid,
+    event_id timeuuid,
+    event_data blob,
+    location frozen<address>,
+    PRIMARY KEY ((tenant_key, user_id), event_id)
+) WITH CLUSTERING ORDER BY (event_id DESC)
+    AND additional_write_policy = '99p'
+    AND bloom_filter_fp_chance = 0.01
+    AND caching = {'keys': 'ALL', 'rows_per_partition': 'NONE'}
+    AND comment = 'Client''s events'
+    AND compaction = {'class': 'org.apache.cassandra.db.compaction.TimeWindowCompactionStrategy', 'compaction_window_size': '1', 'compaction_window_unit': 'DAYS'}
+    AND default_time_to_live = 0
+    AND gc_grace_seconds = 864000;
+
+CREATE INDEX events_user_idx ON tracking.events (user_id);
+
+CREATE MATERIALIZED VIEW tracking.events_by_user AS
+    SELECT *
+    FROM tracking.events
+    WHERE user_id IS NOT NULL AND tenant_key IS NOT NULL AND event_id IS NOT NULL
+    PRIMARY KEY (user_id, tenant_key, event_id)
+ WITH CLUSTERING ORDER BY (tenant_key ASC, event_id DESC);
+
+CREATE FUNCTION tracking.plus(a int, b int)
+    RETURNS NULL ON NULL INPUT
+    RETURNS int
+    LANGUAGE java
+    AS $$ return a + b; $$;
+
+CREATE TABLE tracking.users (
+    user_id uuid PRIMARY KEY,
+    name text
+) WITH comment = '';
+`
+
+func TestParseDescribe(t *testing.T) {
+	tables, types, err := ParseDescribe(describeKeyspaceOutput)
+	require.NoError(t, err)
+
+	require.Len(t, types, 1)
+	require.Equal(t, "tracking", types[0].Keyspace)
+	require.Equal(t, "address", types[0].Name)
+	require.Len(t, types[0].Fields, 2)
+
+	require.Len(t, tables, 2)
+
+	events := tables[0]
+	require.Equal(t, "tracking", events.Keyspace)
+	require.Equal(t, "events", events.TableName)
+	require.Equal(t, []string{"-- Events sent by the clients"}, events.Comments)
+	require.Equal(t, "(tenant_key, user_id)", events.PrimaryKey.PartitionKey.String())
+	require.Equal(t, []ClusteringOrder{{Column: "event_id", Descending: true}}, events.ClusteringOrder)
+	require.Equal(t, "Client's events", events.Comment())
+	require.Len(t, events.Options, 7)
+
+	caching, ok := events.Option("caching")
+	require.True(t, ok)
+	require.Equal(t, "{'keys': 'ALL', 'rows_per_partition': 'NONE'}", caching)
+
+	require.Equal(t, "users", tables[1].TableName)
+	require.Equal(t, "", tables[1].Comment())
+}
+
+func TestFormatTableOptionsRoundTrip(t *testing.T) {
+	tables, _, err := ParseDescribe(describeKeyspaceOutput)
+	require.NoError(t, err)
+
+	const exp = `-- Events sent by the clients
+CREATE TABLE tracking.events (
+	tenant_key bigint,
+	user_id uuid,
+	event_id timeuuid,
+	event_data blob,
+	location frozen<address>,
+	PRIMARY KEY ((tenant_key, user_id), event_id)
+) WITH CLUSTERING ORDER BY (event_id DESC)
+	AND additional_write_policy = '99p'
+	AND bloom_filter_fp_chance = 0.01
+	AND caching = {'keys': 'ALL', 'rows_per_partition': 'NONE'}
+	AND comment = 'Client''s events'
+	AND compaction = {'class': 'org.apache.cassandra.db.compaction.TimeWindowCompactionStrategy', 'compaction_window_size': '1', 'compaction_window_unit': 'DAYS'}
+	AND default_time_to_live = 0
+	AND gc_grace_seconds = 864000;
+`
+
+	formatted := Format(tables[0])
+	require.Equal(t, exp, formatted)
+
+	reparsed, err := ParseSchema(formatted)
+	require.NoError(t, err)
+
+	clearPositions(&tables[0])
+	clearPositions(&reparsed)
+	require.Equal(t, tables[0], reparsed)
+}
@@ -0,0 +1,144 @@
+package cql
+
+import (
+	"strings"
+)
+
+// Format renders the schema as canonical CQL.
+//
+// The output can be parsed back by ParseSchema and yields the same schema, minus the source positions.
+func Format(schema Schema) string {
+	var sb strings.Builder
+
+	writeComments(&sb, "", schema.Comments)
+
+	sb.WriteString("CREATE TABLE ")
+	if schema.IfNotExists {
+		sb.WriteString("IF NOT EXISTS ")
+	}
+	sb.WriteString(schema.QualifiedName())
+	sb.WriteString(" (\n")
+
+	hasInlinePrimaryKey := false
+	for i, column := range schema.Columns {
+		if i > 0 {
+			sb.WriteString(",\n")
+		}
+
+		writeComments(&sb, "\t", column.Comments)
+		sb.WriteString("\t")
+		sb.WriteString(column.Name)
+		sb.WriteString(" ")
+		sb.WriteString(formatTypeName(column.Type.Name))
+		if column.PrimaryKey {
+			sb.WriteString(" PRIMARY KEY")
+			hasInlinePrimaryKey = true
+		}
+		if column.Static {
+			sb.WriteString(" STATIC")
+		}
+	}
+
+	// Only write the table level primary key if it's not already declared inline
+	if len(schema.PrimaryKey.Columns()) > 0 && (!hasInlinePrimaryKey || schema.PrimaryKey.Pos.IsValid()) {
+		sb.WriteString(",\n")
+		writeComments(&sb, "\t", schema.PrimaryKey.Comments)
+		sb.WriteString("\tPRIMARY KEY ")
+		sb.WriteString(formatPrimaryKey(schema.PrimaryKey))
+	}
+
+	sb.WriteString("\n)")
+
+	// Write the table options, each on its own line
+
+	var options []string
+	if len(schema.ClusteringOrder) > 0 {
+		options = append(options, "CLUSTERING ORDER BY "+formatClusteringOrder(schema.ClusteringOrder))
+	}
+	for _, option := range schema.Options {
+		if option.Value == "" {
+			options = append(options, option.Name)
+		} else {
+			options = append(options, option.Name+" = "+option.Value)
+		}
+	}
+
+	for i, option := range options {
+		if i == 0 {
+			sb.WriteString(" WITH ")
+		} else {
+			sb.WriteString("\n\tAND ")
+		}
+		sb.WriteString(option)
+	}
+
+	sb.WriteString(";\n")
+
+	return sb.String()
+}
+
+// FormatSchemas renders all schemas as canonical CQL, separated by an empty line.
+func FormatSchemas(schemas []Schema) string {
+	var sb strings.Builder
+
+	for i, schema := range schemas {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(Format(schema))
+	}
+
+	return sb.String()
+}
+
+func writeComments(sb *strings.Builder, indent string, comments []string) {
+	for _, comment := range comments {
+		sb.WriteString(indent)
+		sb.WriteString(comment)
+		sb.WriteString("\n")
+	}
+}
+
+func formatClusteringOrder(order []ClusteringOrder) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+
+	for i, column := range order {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(column.Column)
+		if column.Descending {
+			sb.WriteString(" DESC")
+		} else {
+			sb.WriteString(" ASC")
+		}
+	}
+
+	sb.WriteString(")")
+	return sb.String()
+}
+
+func formatTypeName(name string) string {
+	return strings.ReplaceAll(name, ",", ", ")
+}
+
+func formatPrimaryKey(primaryKey PrimaryKey) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+
+	partitionKey := primaryKey.PartitionKey.Columns
+	if len(partitionKey) == 1 {
+		sb.WriteString(partitionKey[0].Name)
+	} else {
+		sb.WriteString(primaryKey.PartitionKey.String())
+	}
+
+	for _, column := range primaryKey.ClusteringKey.Columns {
+		sb.WriteString(", ")
+		sb.WriteString(column.Name)
+	}
+
+	sb.WriteString(")")
+	return sb.String()
+}
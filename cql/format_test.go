@@ -0,0 +1,104 @@
+package cql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	const input = `create table if not exists events(tenant_key bigint,
+		user_id uuid, event_category text,
+	event_id timeuuid, tags map<text,   frozen<list<int>>>,
+		owner text static,
+		PRIMARY KEY ((tenant_key, user_id, event_category), event_id))`
+
+	const exp = `CREATE TABLE IF NOT EXISTS events (
+	tenant_key bigint,
+	user_id uuid,
+	event_category text,
+	event_id timeuuid,
+	tags map<text, frozen<list<int>>>,
+	owner text STATIC,
+	PRIMARY KEY ((tenant_key, user_id, event_category), event_id)
+);
+`
+
+	schema, err := ParseSchema(input)
+	require.NoError(t, err)
+	require.Equal(t, exp, Format(schema))
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	testCases := []string{
+		`CREATE TABLE events(
+			user_id uuid,
+			event_data blob,
+			PRIMARY KEY (user_id)
+		);`,
+		`CREATE TABLE events(
+			user_id uuid,
+			partition int,
+			event_category tinyint,
+			event_id timeuuid,
+			event_data blob,
+			PRIMARY KEY ((user_id, partition), event_category, event_id)
+		);`,
+		`CREATE TABLE events(
+			user_id uuid PRIMARY KEY,
+			event_data map<bigint, text>
+		);`,
+		`CREATE TABLE IF NOT EXISTS events(
+			user_id uuid,
+			event_id timeuuid,
+			name text STATIC,
+			tags frozen<set<text>>,
+			PRIMARY KEY (user_id, event_id)
+		);`,
+		`-- The events
+		CREATE TABLE events(
+			/* Who sent the event */
+			user_id uuid,
+			event_id timeuuid, // When
+			PRIMARY KEY (user_id, event_id)
+		) WITH CLUSTERING ORDER BY (event_id DESC) AND comment = 'events';`,
+	}
+
+	for _, input := range testCases {
+		t.Run("", func(t *testing.T) {
+			schema, err := ParseSchema(input)
+			require.NoError(t, err)
+
+			formatted := Format(schema)
+
+			reparsed, err := ParseSchema(formatted)
+			require.NoError(t, err)
+			require.Equal(t, formatted, Format(reparsed))
+
+			clearPositions(&schema)
+			clearPositions(&reparsed)
+			require.Equal(t, schema, reparsed)
+		})
+	}
+}
+
+func TestFormatSchemas(t *testing.T) {
+	const input = `CREATE TABLE users(user_id uuid PRIMARY KEY, name text);
+CREATE TABLE events(user_id uuid, event_id timeuuid, PRIMARY KEY (user_id, event_id));`
+
+	const exp = `CREATE TABLE users (
+	user_id uuid PRIMARY KEY,
+	name text
+);
+
+CREATE TABLE events (
+	user_id uuid,
+	event_id timeuuid,
+	PRIMARY KEY (user_id, event_id)
+);
+`
+
+	schemas, err := ParseSchemas(input)
+	require.NoError(t, err)
+	require.Equal(t, exp, FormatSchemas(schemas))
+}
@@ -1,16 +1,33 @@
 package cql
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 var (
-	errEOF = errors.New("end of file")
+	errEOF                = newError("end of file")
+	errUnterminatedString = newError("unterminated string literal")
 )
 
+// Position is a location in the CQL source.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// IsValid returns true if the position points to an actual location.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
 type token string
 
 func (t token) String() string {
@@ -18,15 +35,22 @@ func (t token) String() string {
 }
 
 type lexer struct {
-	data string
-	pos  int
+	data  string
+	pos   int
+	start int
 
 	undoStack []int
+
+	// comments contains the comments skipped since the last call to TakeComments.
+	comments []string
+	// lastComment is the offset of the last comment seen, it's used to not collect a comment twice after an undo.
+	lastComment int
 }
 
 func newLexer(data string) *lexer {
 	return &lexer{
-		data: data,
+		data:        data,
+		lastComment: -1,
 	}
 }
 
@@ -63,14 +87,37 @@ func (l *lexer) Next() (token, error) {
 	}
 
 	l.undoStack = append(l.undoStack, l.pos)
+	l.start = l.pos
 
 	switch ch := l.char(); ch {
-	case '(', ')', '<', '>', ',', ';':
+	case '(', ')', '<', '>', ',', ';', '{', '}', '[', ']', ':', '=':
 		l.pos++
 
 		return token(ch), nil
 
+	case '\'':
+		tmp, err := l.readStringLiteral()
+		if err != nil {
+			return "", err
+		}
+
+		l.pos += len(tmp)
+
+		return token(tmp), nil
+
 	default:
+		if strings.HasPrefix(l.view(), "$$") {
+			end := strings.Index(l.view()[2:], "$$")
+			if end == -1 {
+				return "", errUnterminatedString
+			}
+
+			tmp := l.view()[:end+4]
+			l.pos += len(tmp)
+
+			return token(tmp), nil
+		}
+
 		tmp := l.readUntil(isTerminator)
 		if tmp == "" {
 			tmp = l.view()
@@ -82,17 +129,98 @@ func (l *lexer) Next() (token, error) {
 	}
 }
 
-func (l *lexer) eatWhitespace() error {
+// readStringLiteral reads a single quoted string literal, quotes included.
+// Quotes inside the literal are escaped by doubling them.
+func (l *lexer) readStringLiteral() (string, error) {
 	data := l.view()
 
-	for _, ch := range data {
-		if !unicode.IsSpace(ch) {
-			return nil
+	for i := 1; i < len(data); i++ {
+		if data[i] != '\'' {
+			continue
 		}
-		l.pos++
+		if i+1 < len(data) && data[i+1] == '\'' {
+			i++
+			continue
+		}
+
+		return data[:i+1], nil
 	}
 
-	return fmt.Errorf("no more whitespace to be found, err: %w", errEOF)
+	return "", errUnterminatedString
+}
+
+// TakeComments returns the comments skipped so far and forgets them.
+func (l *lexer) TakeComments() []string {
+	res := l.comments
+	l.comments = nil
+	return res
+}
+
+func (l *lexer) addComment(comment string) {
+	if l.pos <= l.lastComment {
+		return
+	}
+
+	l.comments = append(l.comments, strings.TrimRightFunc(comment, unicode.IsSpace))
+	l.lastComment = l.pos
+}
+
+// Position returns the line and column of the given offset in the data.
+func (l *lexer) Position(offset int) Position {
+	res := Position{
+		Offset: offset,
+		Line:   1,
+		Column: 1,
+	}
+
+	for _, ch := range l.data[:offset] {
+		if ch == '\n' {
+			res.Line++
+			res.Column = 1
+		} else {
+			res.Column++
+		}
+	}
+
+	return res
+}
+
+// eatWhitespace skips whitespace and comments.
+func (l *lexer) eatWhitespace() error {
+	for l.pos < len(l.data) {
+		data := l.view()
+
+		switch {
+		case strings.HasPrefix(data, "--"), strings.HasPrefix(data, "//"):
+			end := strings.IndexByte(data, '\n')
+			if end == -1 {
+				end = len(data)
+			}
+
+			l.addComment(data[:end])
+			l.pos += end
+
+		case strings.HasPrefix(data, "/*"):
+			end := strings.Index(data[2:], "*/")
+			if end == -1 {
+				end = len(data)
+			} else {
+				end += 4
+			}
+
+			l.addComment(data[:end])
+			l.pos += end
+
+		default:
+			ch, size := utf8.DecodeRuneInString(data)
+			if !unicode.IsSpace(ch) {
+				return nil
+			}
+			l.pos += size
+		}
+	}
+
+	return newError("no more whitespace to be found, err: %s", errEOF)
 }
 
 func (l *lexer) readUntil(predicate func(rune) bool) string {
@@ -111,9 +239,10 @@ func isTerminator(ch rune) bool {
 		return true
 	}
 
-	if ch == ',' {
+	switch ch {
+	case ',', ';', '=', ':', '(', ')', '<', '>', '{', '}', '[', ']':
 		return true
+	default:
+		return false
 	}
-
-	return ch == '(' || ch == ')' || ch == '<' || ch == '>'
 }
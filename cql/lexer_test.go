@@ -108,6 +108,21 @@ func TestLexer(t *testing.T) {
 				";",
 			},
 		},
+		{
+			input: `-- a comment
+			CREATE TABLE events(
+				user_id uuid PRIMARY KEY /* inline */
+			) WITH comment = 'it''s a table' AND caching = {'keys': 'ALL'};`,
+			exp: []token{
+				"CREATE", "TABLE", "events",
+				"(",
+				"user_id", "uuid", "PRIMARY", "KEY",
+				")",
+				"WITH", "comment", "=", "'it''s a table'",
+				"AND", "caching", "=", "{", "'keys'", ":", "'ALL'", "}",
+				";",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
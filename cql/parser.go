@@ -3,13 +3,45 @@ package cql
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"unicode"
 )
 
 var (
-	errInvalidType = errors.New("invalid type")
+	errInvalidType = newError("invalid type")
 )
 
+// messageError is an error whose message can be translated, see MessageFormat.
+type messageError struct {
+	format string
+	args   []any
+}
+
+// newError creates an error formatted like fmt.Sprintf, the errors in args are wrapped.
+func newError(format string, args ...any) *messageError {
+	return &messageError{format: format, args: args}
+}
+
+func (e *messageError) Error() string {
+	return fmt.Sprintf(e.format, e.args...)
+}
+
+func (e *messageError) Unwrap() []error {
+	var res []error
+	for _, arg := range e.args {
+		if err, ok := arg.(error); ok {
+			res = append(res, err)
+		}
+	}
+	return res
+}
+
+// MessageFormat returns the format and the arguments of the message, for its translation.
+func (e *messageError) MessageFormat() (string, []any) {
+	return e.format, e.args
+}
+
 type invalidTokenError struct {
 	expected string
 	got      string
@@ -19,6 +51,33 @@ func (e *invalidTokenError) Error() string {
 	return fmt.Sprintf("expected %q, got %q", e.expected, e.got)
 }
 
+// MessageFormat returns the format and the arguments of the message, for its translation.
+func (e *invalidTokenError) MessageFormat() (string, []any) {
+	return "expected %q, got %q", []any{e.expected, e.got}
+}
+
+// SyntaxError is an error found while parsing, located at the offending token.
+type SyntaxError struct {
+	// Pos is the start of the offending token.
+	Pos Position
+	// End is the position just after the offending token, it equals Pos if the token is empty.
+	End Position
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// MessageFormat returns the format and the arguments of the message, for its translation.
+func (e *SyntaxError) MessageFormat() (string, []any) {
+	return "%s: %s", []any{e.Pos, e.Err}
+}
+
 func equalsIgnoreCase[A ~string, B ~string](a A, b B) bool {
 	return strings.EqualFold(string(a), string(b))
 }
@@ -27,6 +86,45 @@ type parser struct {
 	lexer *lexer
 }
 
+// tokenPos returns the position of the last token read.
+func (p *parser) tokenPos() Position {
+	return p.lexer.Position(p.lexer.start)
+}
+
+// nextPos returns the position of the next token to be read.
+func (p *parser) nextPos() Position {
+	_ = p.lexer.eatWhitespace()
+	return p.lexer.Position(p.lexer.pos)
+}
+
+// syntaxError locates err at the last token read.
+func (p *parser) syntaxError(err error) error {
+	var syntaxErr *SyntaxError
+	if err == nil || errors.As(err, &syntaxErr) {
+		return err
+	}
+
+	start, end := p.lexer.start, p.lexer.pos
+	switch {
+	case errors.Is(err, errEOF):
+		// Point at the end of the last statement rather than at trailing whitespace
+		start = len(strings.TrimRightFunc(p.lexer.data, unicode.IsSpace))
+		end = start
+	case errors.Is(err, errUnterminatedString):
+		end = len(p.lexer.data)
+	}
+	if end < start {
+		// The token was undone
+		end = start
+	}
+
+	return &SyntaxError{
+		Pos: p.lexer.Position(start),
+		End: p.lexer.Position(end),
+		Err: err,
+	}
+}
+
 func parseStrings(p *parser, expectedTokens ...string) error {
 	for _, expectedToken := range expectedTokens {
 		token, err := p.lexer.Next()
@@ -50,11 +148,12 @@ func parseOptionalStrings(p *parser, expectedTokens ...string) bool {
 	for _, expectedToken := range expectedTokens {
 		token, err := p.lexer.Next()
 		if err != nil {
+			p.lexer.UndoAll()
 			return false
 		}
 
 		if !equalsIgnoreCase(token, expectedToken) {
-			p.lexer.Undo()
+			p.lexer.UndoAll()
 			return false
 		}
 	}
@@ -73,21 +172,21 @@ func parseNextStringInto[T ~string](p *parser, dest *T) error {
 	return nil
 }
 
-func (p *parser) parseCreateTable() (tableName string, err error) {
+func (p *parser) parseCreateTable() (tableName string, ifNotExists bool, err error) {
 	// 1. Parse the CREATE TABLE
 	if err = parseStrings(p, "CREATE", "TABLE"); err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	// 2. Eat the IF NOT EXISTS if present
-	parseOptionalStrings(p, "IF", "NOT", "EXISTS")
+	ifNotExists = parseOptionalStrings(p, "IF", "NOT", "EXISTS")
 
 	// 3. Get the table name
 	if err = parseNextStringInto(p, &tableName); err != nil {
-		return "", err
+		return "", false, err
 	}
 
-	return tableName, nil
+	return tableName, ifNotExists, nil
 }
 
 func (p *parser) parseColumnDefinitions() (columns ColumnDefinitions, primaryKey PrimaryKey, err error) {
@@ -101,11 +200,16 @@ loop:
 		// In this loop we can process two things: a column definition and a primary key definition
 		// There are 1 or more column definitions. The primary key definition is optional.
 
+		pos := p.nextPos()
+		comments := p.lexer.TakeComments()
+
 		switch {
 		case parseOptionalStrings(p, "PRIMARY", "KEY"):
 			if primaryKey, err = p.parsePrimaryKey(columns); err != nil {
 				return
 			}
+			primaryKey.Pos = pos
+			primaryKey.Comments = comments
 
 		default:
 			var (
@@ -115,13 +219,14 @@ loop:
 			if columnDefinition, isPrimaryKey, err = p.parseColumnDefinition(); err != nil {
 				break loop
 			}
+			columnDefinition.Comments = comments
 
 			// Check if the column is a primary key
 
 			if isPrimaryKey {
 				// It's an error if we already have a primary key
 				if len(primaryKey.Columns()) > 0 {
-					err = fmt.Errorf("multiple primary keys defined")
+					err = newError("multiple primary keys defined")
 					return
 				}
 
@@ -155,6 +260,7 @@ func (p *parser) parseColumnDefinition() (res ColumnDefinition, primaryKey bool,
 	if err = parseNextStringInto(p, &res.Name); err != nil {
 		return
 	}
+	res.Pos = p.tokenPos()
 
 	// Parse the type
 	if res.Type, err = p.parseTypeDefinition(); err != nil {
@@ -163,6 +269,7 @@ func (p *parser) parseColumnDefinition() (res ColumnDefinition, primaryKey bool,
 
 	// Parse the optional PRIMARY KEY
 	primaryKey = parseOptionalStrings(p, "PRIMARY", "KEY")
+	res.PrimaryKey = primaryKey
 
 	// Parse the optional STATIC
 	res.Static = parseOptionalStrings(p, "STATIC")
@@ -176,13 +283,14 @@ func (p *parser) parseTypeDefinition() (res DataType, err error) {
 		return
 	}
 
-	// 2. Return early if it's not a collection
-	if res.Name != "set" && res.Name != "map" && res.Name != "list" {
+	// 2. Return early if it's not a parameterized type
+	switch res.Name {
+	case "set", "map", "list", "frozen", "tuple":
+	default:
 		return
 	}
 
-	// It is a collection, continue parsing.
-	// We parse differently if it's a set/list or a map
+	// It is a parameterized type, continue parsing until the matching >
 
 	var (
 		token token
@@ -239,7 +347,7 @@ func (p *parser) parsePrimaryKey(columns ColumnDefinitions) (primaryKey PrimaryK
 				columnName := token.String()
 				columnDefinition, ok := columns.FindByName(columnName)
 				if !ok {
-					return res, fmt.Errorf("invalid column %q in primary key", columnName)
+					return res, newError("invalid column %q in primary key", columnName)
 				}
 
 				res = append(res, columnDefinition)
@@ -274,7 +382,7 @@ func (p *parser) parsePrimaryKey(columns ColumnDefinitions) (primaryKey PrimaryK
 		columnName := token.String()
 		columnDefinition, ok := columns.FindByName(columnName)
 		if !ok {
-			return primaryKey, fmt.Errorf("invalid column %q in primary key", columnName)
+			return primaryKey, newError("invalid column %q in primary key", columnName)
 		}
 
 		primaryKey.PartitionKey.Columns = append(primaryKey.ClusteringKey.Columns, columnDefinition)
@@ -289,22 +397,179 @@ func (p *parser) parsePrimaryKey(columns ColumnDefinitions) (primaryKey PrimaryK
 }
 
 func (p *parser) parse() (schema Schema, err error) {
-	if schema.TableName, err = p.parseCreateTable(); err != nil {
+	schema.Pos = p.nextPos()
+	schema.Comments = p.lexer.TakeComments()
+
+	var name string
+	if name, schema.IfNotExists, err = p.parseCreateTable(); err != nil {
 		return
 	}
+	schema.Keyspace, schema.TableName = splitQualifiedName(name)
 
 	if schema.Columns, schema.PrimaryKey, err = p.parseColumnDefinitions(); err != nil {
 		return
 	}
 
+	if parseOptionalStrings(p, "WITH") {
+		if err = p.parseTableOptions(&schema); err != nil {
+			return
+		}
+	}
+
 	return
 }
 
+func (p *parser) parseTableOptions(schema *Schema) (err error) {
+	for {
+		switch {
+		case parseOptionalStrings(p, "CLUSTERING", "ORDER", "BY"):
+			if schema.ClusteringOrder, err = p.parseClusteringOrder(); err != nil {
+				return err
+			}
+
+		case parseOptionalStrings(p, "COMPACT", "STORAGE"):
+			schema.Options = append(schema.Options, Option{Name: "COMPACT STORAGE"})
+
+		default:
+			var option Option
+			if err = parseNextStringInto(p, &option.Name); err != nil {
+				return err
+			}
+			if err = parseStrings(p, "="); err != nil {
+				return err
+			}
+			if option.Value, err = p.parseOptionValue(); err != nil {
+				return err
+			}
+
+			schema.Options = append(schema.Options, option)
+		}
+
+		// Options are separated by AND
+		if !parseOptionalStrings(p, "AND") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseClusteringOrder() (res []ClusteringOrder, err error) {
+	if err = parseStrings(p, "("); err != nil {
+		return
+	}
+
+	for {
+		var order ClusteringOrder
+		if err = parseNextStringInto(p, &order.Column); err != nil {
+			return
+		}
+
+		switch {
+		case parseOptionalStrings(p, "DESC"):
+			order.Descending = true
+		default:
+			parseOptionalStrings(p, "ASC")
+		}
+
+		res = append(res, order)
+
+		if !parseOptionalStrings(p, ",") {
+			break
+		}
+	}
+
+	err = parseStrings(p, ")")
+
+	return
+}
+
+// parseOptionValue parses the value of a table option up to the next AND or ;
+// The value is returned normalized as a single line.
+func (p *parser) parseOptionValue() (string, error) {
+	var (
+		sb    strings.Builder
+		prev  token
+		level int
+	)
+
+	for {
+		tok, err := p.lexer.Next()
+		if errors.Is(err, errEOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if level == 0 && (tok == ";" || equalsIgnoreCase(tok, "AND")) {
+			p.lexer.Undo()
+			break
+		}
+
+		switch tok {
+		case "{", "[", "(":
+			level++
+		case "}", "]", ")":
+			level--
+		}
+
+		// Put a space between tokens except around brackets and before separators
+		switch {
+		case prev == "" || prev == "{" || prev == "[" || prev == "(":
+		case tok == "}" || tok == "]" || tok == ")" || tok == "," || tok == ":":
+		default:
+			sb.WriteString(" ")
+		}
+
+		sb.WriteString(tok.String())
+		prev = tok
+	}
+
+	if sb.Len() == 0 {
+		return "", newError("missing option value")
+	}
+
+	return sb.String(), nil
+}
+
+// splitQualifiedName splits a name of the form keyspace.name
+func splitQualifiedName(name string) (keyspace string, res string) {
+	if pos := strings.IndexByte(name, '.'); pos != -1 {
+		return name[:pos], name[pos+1:]
+	}
+	return "", name
+}
+
 func ParseSchema(schema string) (Schema, error) {
 	parser := &parser{
 		lexer: newLexer(schema),
 	}
-	return parser.parse()
+
+	res, err := parser.parse()
+	if err != nil {
+		return Schema{}, parser.syntaxError(err)
+	}
+
+	return res, nil
+}
+
+// ParseSchemas parses all the CREATE TABLE statements in data.
+// Statements can optionally be terminated by a ;
+func ParseSchemas(data string) (res []Schema, err error) {
+	parser := &parser{
+		lexer: newLexer(data),
+	}
+
+	for parser.lexer.eatWhitespace() == nil {
+		var schema Schema
+		if schema, err = parser.parse(); err != nil {
+			return nil, parser.syntaxError(err)
+		}
+		res = append(res, schema)
+
+		parseOptionalStrings(parser, ";")
+	}
+
+	return res, nil
 }
 
 type DataType struct {
@@ -338,10 +603,33 @@ func (t DataType) Size() int {
 	}
 }
 
+// IsFrozen returns true if the type is wrapped in frozen<>.
+func (t DataType) IsFrozen() bool {
+	return strings.HasPrefix(t.Name, "frozen<")
+}
+
+// IsCounter returns true if the type is a counter.
+func (t DataType) IsCounter() bool {
+	return t.Name == "counter"
+}
+
+// IsCollection returns true if the type is a set, list or map, frozen or not.
+func (t DataType) IsCollection() bool {
+	name := strings.TrimPrefix(t.Name, "frozen<")
+
+	return strings.HasPrefix(name, "set<") ||
+		strings.HasPrefix(name, "list<") ||
+		strings.HasPrefix(name, "map<")
+}
+
 type ColumnDefinition struct {
-	Name   string
-	Type   DataType
-	Static bool
+	Pos      Position
+	Comments []string
+	Name     string
+	Type     DataType
+	Static   bool
+	// PrimaryKey is true if the column is declared inline as the primary key.
+	PrimaryKey bool
 
 	sizeEstimate int
 }
@@ -424,6 +712,10 @@ func (k ClusteringKey) String() string {
 }
 
 type PrimaryKey struct {
+	// Pos is the position of the table level PRIMARY KEY clause.
+	// It is not valid if the primary key is declared inline or not at all.
+	Pos           Position
+	Comments      []string
 	PartitionKey  PartitionKey
 	ClusteringKey ClusteringKey
 }
@@ -436,9 +728,81 @@ func (c PrimaryKey) Columns() ColumnDefinitions {
 }
 
 type Schema struct {
-	TableName  string
-	Columns    ColumnDefinitions
-	PrimaryKey PrimaryKey
+	Pos         Position
+	Comments    []string
+	Keyspace    string
+	TableName   string
+	IfNotExists bool
+	Columns     ColumnDefinitions
+	PrimaryKey  PrimaryKey
+
+	ClusteringOrder []ClusteringOrder
+	Options         []Option
+}
+
+// ClusteringOrder is the order of a clustering column as defined by WITH CLUSTERING ORDER BY.
+type ClusteringOrder struct {
+	Column     string
+	Descending bool
+}
+
+// Option is a table option defined in the WITH clause.
+//
+// The value is kept as a CQL literal, for example 'a string', 0.01 or {'class': 'LeveledCompactionStrategy'}.
+type Option struct {
+	Name  string
+	Value string
+}
+
+// Option returns the value of the table option with the given name.
+func (s Schema) Option(name string) (string, bool) {
+	for _, option := range s.Options {
+		if equalsIgnoreCase(option.Name, name) {
+			return option.Value, true
+		}
+	}
+	return "", false
+}
+
+// Comment returns the table comment defined with the comment option.
+func (s Schema) Comment() string {
+	value, ok := s.Option("comment")
+	if !ok {
+		return ""
+	}
+	return unquoteString(value)
+}
+
+// QualifiedName returns the table name prefixed by its keyspace if there's one.
+func (s Schema) QualifiedName() string {
+	if s.Keyspace == "" {
+		return s.TableName
+	}
+	return s.Keyspace + "." + s.TableName
+}
+
+func unquoteString(s string) string {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return s
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+}
+
+// QuoteString returns s as a CQL string literal.
+func QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Clone returns a copy of the schema whose columns can be modified, for example
+// with WithColumnSizeEstimate, without modifying the original schema.
+func (s Schema) Clone() Schema {
+	s.Columns = slices.Clone(s.Columns)
+	s.PrimaryKey.PartitionKey.Columns = slices.Clone(s.PrimaryKey.PartitionKey.Columns)
+	s.PrimaryKey.ClusteringKey.Columns = slices.Clone(s.PrimaryKey.ClusteringKey.Columns)
+	s.ClusteringOrder = slices.Clone(s.ClusteringOrder)
+	s.Options = slices.Clone(s.Options)
+
+	return s
 }
 
 func (s Schema) WithColumnSizeEstimate(name string, sizeEstimate int) Schema {
@@ -13,6 +13,21 @@ func mkColumnDef(name string, typ string) ColumnDefinition {
 	}
 }
 
+// clearPositions removes the source positions from the schema so it can be compared to a hand written one.
+func clearPositions(schema *Schema) {
+	clearColumns := func(columns ColumnDefinitions) {
+		for i := range columns {
+			columns[i].Pos = Position{}
+		}
+	}
+
+	schema.Pos = Position{}
+	schema.PrimaryKey.Pos = Position{}
+	clearColumns(schema.Columns)
+	clearColumns(schema.PrimaryKey.PartitionKey.Columns)
+	clearColumns(schema.PrimaryKey.ClusteringKey.Columns)
+}
+
 func TestParser(t *testing.T) {
 	testCases := []struct {
 		input  string
@@ -87,6 +102,7 @@ func TestParser(t *testing.T) {
 				},
 			},
 			modify: func(exp *Schema) {
+				exp.Columns[0].PrimaryKey = true
 				exp.PrimaryKey.PartitionKey.Columns = ColumnDefinitions{exp.Columns[0]}
 			},
 		},
@@ -103,6 +119,7 @@ func TestParser(t *testing.T) {
 				},
 			},
 			modify: func(exp *Schema) {
+				exp.Columns[0].PrimaryKey = true
 				exp.Columns[1].Static = true
 				exp.PrimaryKey.PartitionKey.Columns = ColumnDefinitions{exp.Columns[0]}
 			},
@@ -120,6 +137,26 @@ func TestParser(t *testing.T) {
 				},
 			},
 			modify: func(exp *Schema) {
+				exp.Columns[0].PrimaryKey = true
+				exp.PrimaryKey.PartitionKey.Columns = ColumnDefinitions{exp.Columns[0]}
+			},
+		},
+		{
+			input: `CREATE TABLE events(
+				user_id uuid PRIMARY KEY,
+				tags frozen<list<text>>,
+				point tuple<int, int>
+			);`,
+			exp: Schema{
+				TableName: "events",
+				Columns: ColumnDefinitions{
+					mkColumnDef("user_id", "uuid"),
+					mkColumnDef("tags", "frozen<list<text>>"),
+					mkColumnDef("point", "tuple<int,int>"),
+				},
+			},
+			modify: func(exp *Schema) {
+				exp.Columns[0].PrimaryKey = true
 				exp.PrimaryKey.PartitionKey.Columns = ColumnDefinitions{exp.Columns[0]}
 			},
 		},
@@ -129,13 +166,15 @@ func TestParser(t *testing.T) {
 				name text
 			);`,
 			exp: Schema{
-				TableName: "events",
+				TableName:   "events",
+				IfNotExists: true,
 				Columns: ColumnDefinitions{
 					mkColumnDef("user_id", "uuid"),
 					mkColumnDef("name", "text"),
 				},
 			},
 			modify: func(exp *Schema) {
+				exp.Columns[0].PrimaryKey = true
 				exp.PrimaryKey.PartitionKey.Columns = ColumnDefinitions{exp.Columns[0]}
 			},
 		},
@@ -149,7 +188,103 @@ func TestParser(t *testing.T) {
 
 			schema, err := ParseSchema(tc.input)
 			require.NoError(t, err)
+
+			clearPositions(&schema)
 			require.Equal(t, tc.exp, schema)
 		})
 	}
 }
+
+func TestParserPositions(t *testing.T) {
+	const input = `CREATE TABLE events(
+	user_id uuid,
+	event_data blob,
+	PRIMARY KEY (user_id)
+);`
+
+	schema, err := ParseSchema(input)
+	require.NoError(t, err)
+
+	require.Equal(t, Position{Offset: 0, Line: 1, Column: 1}, schema.Pos)
+	require.Equal(t, Position{Offset: 22, Line: 2, Column: 2}, schema.Columns[0].Pos)
+	require.Equal(t, Position{Offset: 37, Line: 3, Column: 2}, schema.Columns[1].Pos)
+	require.Equal(t, Position{Offset: 55, Line: 4, Column: 2}, schema.PrimaryKey.Pos)
+	require.Equal(t, schema.Columns[0].Pos, schema.PrimaryKey.PartitionKey.Columns[0].Pos)
+}
+
+func TestParseSchemas(t *testing.T) {
+	const input = `CREATE TABLE users(
+	user_id uuid PRIMARY KEY,
+	name text
+);
+
+CREATE TABLE events(
+	user_id uuid,
+	event_id timeuuid,
+	PRIMARY KEY (user_id, event_id)
+)`
+
+	schemas, err := ParseSchemas(input)
+	require.NoError(t, err)
+	require.Len(t, schemas, 2)
+
+	require.Equal(t, "users", schemas[0].TableName)
+	require.Equal(t, "events", schemas[1].TableName)
+	require.Equal(t, "(event_id)", schemas[1].PrimaryKey.ClusteringKey.String())
+}
+
+func TestSchemaClone(t *testing.T) {
+	schema, err := ParseSchema("CREATE TABLE events(user_id uuid, name text, data blob, PRIMARY KEY ((user_id, name), data));")
+	require.NoError(t, err)
+
+	clone := schema.Clone().WithColumnSizeEstimate("name", 10).WithColumnSizeEstimate("data", 20)
+
+	require.Equal(t, 10, clone.Columns[1].Size())
+	require.Equal(t, 10, clone.PrimaryKey.PartitionKey.Columns[1].Size())
+	require.Equal(t, 20, clone.PrimaryKey.ClusteringKey.Columns[0].Size())
+
+	// The original is untouched
+	require.Equal(t, 0, schema.Columns[1].Size())
+	require.Equal(t, 0, schema.PrimaryKey.PartitionKey.Columns[1].Size())
+	require.Equal(t, 0, schema.PrimaryKey.ClusteringKey.Columns[0].Size())
+}
+
+func TestParserSyntaxErrors(t *testing.T) {
+	testCases := []struct {
+		input string
+		pos   Position
+		end   Position
+	}{
+		{
+			input: "CREATE TABLE events(\n\tuser_id uuid,\n\tPRIMARY KEY (id)\n);",
+			pos:   Position{Offset: 50, Line: 3, Column: 15},
+			end:   Position{Offset: 52, Line: 3, Column: 17},
+		},
+		{
+			// Incomplete statements point at their end, not at the trailing whitespace
+			input: "CREATE TABLE events(\n\tuser_id uuid PRIMARY KEY\n\n",
+			pos:   Position{Offset: 46, Line: 2, Column: 26},
+			end:   Position{Offset: 46, Line: 2, Column: 26},
+		},
+		{
+			input: "CREATE TABLE events(user_id uuid PRIMARY KEY) WITH comment = 'abc",
+			pos:   Position{Offset: 61, Line: 1, Column: 62},
+			end:   Position{Offset: 65, Line: 1, Column: 66},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			_, err := ParseSchema(tc.input)
+
+			var syntaxErr *SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			require.Equal(t, tc.pos, syntaxErr.Pos)
+			require.Equal(t, tc.end, syntaxErr.End)
+
+			_, _, err = ParseDescribe(tc.input)
+			require.ErrorAs(t, err, &syntaxErr)
+			require.Equal(t, tc.pos, syntaxErr.Pos)
+		})
+	}
+}
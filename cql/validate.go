@@ -0,0 +1,133 @@
+package cql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Violation is a semantic error found in a parsed schema.
+type Violation struct {
+	Pos     Position
+	Message string
+
+	// format and args are the parts of Message, used to translate it
+	format string
+	args   []any
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Pos, v.Message)
+}
+
+// MessageFormat returns the format and the arguments of Message, for its translation.
+func (v Violation) MessageFormat() (string, []any) {
+	return v.format, v.args
+}
+
+// Violations is the list of all semantic errors found in a schema.
+type Violations []Violation
+
+func (v Violations) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, violation := range v {
+		messages = append(messages, violation.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks that the schema is a valid table definition for Cassandra.
+//
+// The parser accepts some table definitions Cassandra would reject; Validate finds them.
+// It returns nil if the schema is valid, otherwise a Violations containing every problem found.
+func Validate(schema Schema) error {
+	var res Violations
+	report := func(pos Position, format string, args ...interface{}) {
+		res = append(res, Violation{
+			Pos:     pos,
+			Message: fmt.Sprintf(format, args...),
+			format:  format,
+			args:    args,
+		})
+	}
+
+	// Column names must be unique
+
+	for _, column := range DuplicateColumns(schema) {
+		report(column.Pos, "column %q is defined more than once", column.Name)
+	}
+
+	// There must be exactly one primary key
+
+	var inlinePrimaryKeys ColumnDefinitions
+	for _, column := range schema.Columns {
+		if column.PrimaryKey {
+			inlinePrimaryKeys = append(inlinePrimaryKeys, column)
+		}
+	}
+
+	switch {
+	case len(schema.PrimaryKey.Columns()) == 0:
+		report(schema.Pos, "table %q has no PRIMARY KEY", schema.TableName)
+
+	case len(inlinePrimaryKeys) > 0 && schema.PrimaryKey.Pos.IsValid():
+		report(schema.PrimaryKey.Pos, "PRIMARY KEY is already declared inline on column %q", inlinePrimaryKeys[0].Name)
+	}
+
+	// Static columns need clustering columns
+
+	for _, column := range MisplacedStaticColumns(schema) {
+		report(column.Pos, "static column %q is not allowed on a table without clustering columns", column.Name)
+	}
+
+	// Collections in the primary key must be frozen
+
+	for _, column := range schema.PrimaryKey.Columns() {
+		if column.Type.IsCollection() && !column.Type.IsFrozen() {
+			report(column.Pos, "primary key column %q has a non frozen collection type %q", column.Name, column.Type.Name)
+		}
+	}
+
+	// Counters can't be mixed with other columns
+
+	var counters, nonCounters ColumnDefinitions
+	for _, column := range schema.Columns.NotIn(schema.PrimaryKey.Columns()) {
+		if column.Type.IsCounter() {
+			counters = append(counters, column)
+		} else {
+			nonCounters = append(nonCounters, column)
+		}
+	}
+	if len(counters) > 0 {
+		for _, column := range nonCounters {
+			report(column.Pos, "column %q can't be mixed with counter column %q", column.Name, counters[0].Name)
+		}
+	}
+
+	if len(res) > 0 {
+		return res
+	}
+	return nil
+}
+
+// DuplicateColumns returns the columns whose name is already used by a previous column of the schema.
+func DuplicateColumns(schema Schema) ColumnDefinitions {
+	var res ColumnDefinitions
+
+	seen := make(map[string]bool, len(schema.Columns))
+	for _, column := range schema.Columns {
+		if seen[column.Name] {
+			res = append(res, column)
+		}
+		seen[column.Name] = true
+	}
+
+	return res
+}
+
+// MisplacedStaticColumns returns the static columns of the schema if it has no clustering columns.
+func MisplacedStaticColumns(schema Schema) ColumnDefinitions {
+	if len(schema.PrimaryKey.ClusteringKey.Columns) > 0 {
+		return nil
+	}
+	return schema.Columns.GetStaticColumns()
+}
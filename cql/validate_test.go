@@ -0,0 +1,108 @@
+package cql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   Violations
+	}{
+		{
+			input: `CREATE TABLE events(
+	user_id uuid,
+	event_id timeuuid,
+	name text STATIC,
+	PRIMARY KEY (user_id, event_id)
+);`,
+			exp: nil,
+		},
+		{
+			input: `CREATE TABLE events(
+	user_id uuid PRIMARY KEY,
+	name text,
+	name text
+);`,
+			exp: Violations{
+				{Pos: Position{Offset: 61, Line: 4, Column: 2}, Message: `column "name" is defined more than once`},
+			},
+		},
+		{
+			input: `CREATE TABLE events(
+	user_id uuid,
+	name text
+);`,
+			exp: Violations{
+				{Pos: Position{Offset: 0, Line: 1, Column: 1}, Message: `table "events" has no PRIMARY KEY`},
+			},
+		},
+		{
+			input: `CREATE TABLE events(
+	user_id uuid PRIMARY KEY,
+	name text,
+	PRIMARY KEY (user_id)
+);`,
+			exp: Violations{
+				{Pos: Position{Offset: 61, Line: 4, Column: 2}, Message: `PRIMARY KEY is already declared inline on column "user_id"`},
+			},
+		},
+		{
+			input: `CREATE TABLE events(
+	user_id uuid PRIMARY KEY,
+	name text STATIC
+);`,
+			exp: Violations{
+				{Pos: Position{Offset: 49, Line: 3, Column: 2}, Message: `static column "name" is not allowed on a table without clustering columns`},
+			},
+		},
+		{
+			input: `CREATE TABLE events(
+	tags set<text>,
+	other frozen<set<text>>,
+	PRIMARY KEY (tags, other)
+);`,
+			exp: Violations{
+				{Pos: Position{Offset: 22, Line: 2, Column: 2}, Message: `primary key column "tags" has a non frozen collection type "set<text>"`},
+			},
+		},
+		{
+			input: `CREATE TABLE events(
+	user_id uuid PRIMARY KEY,
+	views counter,
+	name text
+);`,
+			exp: Violations{
+				{Pos: Position{Offset: 65, Line: 4, Column: 2}, Message: `column "name" can't be mixed with counter column "views"`},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			schema, err := ParseSchema(tc.input)
+			require.NoError(t, err)
+
+			err = Validate(schema)
+			if tc.exp == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			violations, ok := err.(Violations)
+			require.True(t, ok)
+
+			// The message is kept with its format for its translation
+			for i, violation := range violations {
+				format, args := violation.MessageFormat()
+				require.Equal(t, violation.Message, fmt.Sprintf(format, args...))
+
+				violations[i].format, violations[i].args = "", nil
+			}
+			require.Equal(t, tc.exp, violations)
+		})
+	}
+}
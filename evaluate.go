@@ -2,52 +2,325 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/peterbourgon/ff/v3/ffcli"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
 	"rischmann.fr/cassandra-partition-calculator/cql"
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/lint"
 )
 
+type sizeEstimate struct {
+	table  string
+	column string
+	size   int
+}
+
 type evaluateCommandConfig struct {
 	root *rootCommandConfig
+
+	rows          int64
+	sizeEstimates []sizeEstimate
+	chartPath     string
+	chartMetric   chartMetric
+	// format is the format of the output, a table if empty
+	format reportFormat
+	// units are the units of the sizes of the table and of the Markdown and HTML reports
+	units      format.Units
+	lintConfig lint.Config
+	thresholds cassandra.Thresholds
 }
 
-func newEvaluateCommandConfig(root *rootCommandConfig) *ffcli.Command {
+func newEvaluateCommandConfig(root *rootCommandConfig) (*evaluateCommandConfig, *ffcli.Command) {
 	cfg := &evaluateCommandConfig{
-		root: root,
+		root:        root,
+		rows:        100000,
+		chartMetric: chartMetricBytes,
+		lintConfig:  lint.DefaultConfig(),
+		thresholds:  cassandra.DefaultThresholds(),
 	}
 
 	fs := flag.NewFlagSet("evaluate", flag.ContinueOnError)
+	fs.Int64Var(&cfg.rows, "rows", cfg.rows, "Estimated number of rows in a partition")
+	fs.Func("size", "Size estimate of a variable size column as [table.]column=bytes, can be repeated", func(data string) error {
+		name, value, ok := strings.Cut(data, "=")
+		if !ok {
+			return fmt.Errorf("invalid size estimate %q, expected [table.]column=bytes", data)
+		}
+
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid size estimate %q, err: %w", data, err)
+		}
+		if size < 0 {
+			return fmt.Errorf("invalid size estimate %q, err: %w", data, errNegativeValue)
+		}
+
+		estimate := sizeEstimate{column: name, size: size}
+		if table, column, ok := strings.Cut(name, "."); ok {
+			estimate.table, estimate.column = table, column
+		}
+		cfg.sizeEstimates = append(cfg.sizeEstimates, estimate)
+
+		return nil
+	})
+	fs.StringVar(&cfg.chartPath, "chart", "", "Write a SVG chart of the partitions of every table as the number of rows grows to this file")
+	fs.Func("chart-metric", "Metric of the chart, either bytes or values (default bytes)", func(data string) error {
+		switch metric := chartMetric(data); metric {
+		case chartMetricBytes, chartMetricValues:
+			cfg.chartMetric = metric
+			return nil
+		default:
+			return fmt.Errorf("invalid chart metric %q, expected bytes or values", data)
+		}
+	})
+	fs.Func("format", "Output format, either table, csv, markdown or html (default table)", func(data string) error {
+		if data == "table" {
+			cfg.format = ""
+			return nil
+		}
+
+		format, err := parseReportFormat(data)
+		if err != nil {
+			return err
+		}
+		cfg.format = format
+
+		return nil
+	})
+	fs.TextVar(&cfg.units, "units", format.IEC, "Units of the sizes, either iec, si or a unit like MiB or GB")
+	registerLintFlags(fs, &cfg.lintConfig)
+	registerThresholdFlags(fs, &cfg.thresholds)
 
-	return &ffcli.Command{
+	return cfg, &ffcli.Command{
 		Name:       "evaluate",
-		ShortUsage: "evaluate [flags] <schema file>",
-		ShortHelp:  `evaluate a CQL schema`,
-		FlagSet:    fs,
-		Exec:       cfg.Exec,
+		ShortUsage: "evaluate [flags] <schema file | directory | ->...",
+		ShortHelp:  `evaluate CQL schemas`,
+		LongHelp: strings.TrimSpace(`
+Evaluate the partition size of every table found in the inputs.
+
+An input can be a file, a directory in which all *.cql files are read recursively, or - to read stdin.
+
+With -format csv, markdown or html a report of every table is written instead of the table, including the warnings of the linter.
+`),
+		FlagSet: fs,
+		Exec:    cfg.Exec,
 	}
 }
 
+type evaluateInput struct {
+	path string
+	data string
+}
+
+// readEvaluateInputs reads all the inputs given on the command line.
+// stdin can only be read once so - can't be given several times.
+func readEvaluateInputs(args []string, stdin io.Reader) ([]evaluateInput, error) {
+	var (
+		res       []evaluateInput
+		readStdin bool
+	)
+
+	for _, arg := range args {
+		if arg == "-" {
+			if readStdin {
+				return nil, errors.New("stdin can only be read once, - is given several times")
+			}
+			readStdin = true
+
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read stdin, err: %w", err)
+			}
+			res = append(res, evaluateInput{path: "<stdin>", data: string(data)})
+			continue
+		}
+
+		fi, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to stat input, err: %w", err)
+		}
+
+		if !fi.IsDir() {
+			data, err := os.ReadFile(arg)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read input file, err: %w", err)
+			}
+			res = append(res, evaluateInput{path: arg, data: string(data)})
+			continue
+		}
+
+		err = filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || filepath.Ext(path) != ".cql" {
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("unable to read input file, err: %w", err)
+			}
+			res = append(res, evaluateInput{path: path, data: string(data)})
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func (c *evaluateCommandConfig) applySizeEstimates(schema cql.Schema) cql.Schema {
+	for _, estimate := range c.sizeEstimates {
+		if estimate.table != "" && estimate.table != schema.TableName {
+			continue
+		}
+
+		column, ok := schema.Columns.FindByName(estimate.column)
+		if !ok || column.Type.IsFixedSize() {
+			continue
+		}
+
+		schema = schema.WithColumnSizeEstimate(estimate.column, estimate.size)
+	}
+
+	return schema
+}
+
 func (c *evaluateCommandConfig) Exec(ctx context.Context, args []string) error {
 	if len(args) < 1 {
 		return flag.ErrHelp
 	}
+	if c.rows < 0 {
+		return fmt.Errorf("invalid value %d for flag -rows, err: %w", c.rows, errNegativeValue)
+	}
 
-	input, err := os.ReadFile(args[0])
+	inputs, err := readEvaluateInputs(args, os.Stdin)
 	if err != nil {
-		return fmt.Errorf("unable to read input file, err: %w", err)
+		return err
 	}
 
-	schema, err := cql.ParseSchema(string(input))
-	if err != nil {
-		return fmt.Errorf("unable to parse schema, err: %w", err)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if c.format == "" {
+		fmt.Fprintln(w, "FILE\tTABLE\tPARTITION KEY\tCLUSTERING KEY\tVALUES\tSIZE\t")
 	}
 
-	spew.Dump(schema)
+	var (
+		failures    int
+		tables      int
+		totalValues int
+		totalBytes  int
+
+		// evaluated are the tables in the chart and the report
+		evaluated        []cql.Schema
+		evaluationReport = report{title: "Partition sizes", units: c.units}
+		formatter        = format.New(message.NewPrinter(language.English), c.units)
+	)
+
+	for _, input := range inputs {
+		schemas, _, err := cql.ParseDescribe(input.data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: unable to parse schema, err: %s\n", input.path, err)
+			failures++
+			continue
+		}
+
+		for _, schema := range schemas {
+			if err := cql.Validate(schema); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: invalid table %s, err: %s\n", input.path, schema.QualifiedName(), err)
+				failures++
+				continue
+			}
+
+			schema = c.applySizeEstimates(schema)
+
+			table, err := newReportTable(c.lintConfig, schema, c.rows)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: unable to estimate table %s, err: %s\n", input.path, schema.QualifiedName(), err)
+				failures++
+				continue
+			}
+			table.source = input.path
+
+			estimation := table.estimation
+
+			if c.format == "" {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+					input.path,
+					schema.QualifiedName(),
+					schema.PrimaryKey.PartitionKey,
+					schema.PrimaryKey.ClusteringKey,
+					formatter.Number(int64(estimation.Values)),
+					formatter.Bytes(int64(estimation.Bytes)),
+				)
+			}
+
+			evaluated = append(evaluated, schema)
+			evaluationReport.tables = append(evaluationReport.tables, table)
+
+			tables++
+			totalValues += estimation.Values
+			totalBytes += estimation.Bytes
+		}
+	}
+
+	if c.format == "" {
+		fmt.Fprintf(w, "TOTAL\t%d tables\t\t\t%s\t%s\t\n", tables, formatter.Number(int64(totalValues)), formatter.Bytes(int64(totalBytes)))
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	} else {
+		if err := evaluationReport.write(ctx, os.Stdout, c.format); err != nil {
+			return fmt.Errorf("unable to write report, err: %w", err)
+		}
+	}
+
+	if c.chartPath != "" && len(evaluated) > 0 {
+		if err := c.writeChart(ctx, formatter, evaluated); err != nil {
+			return err
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d tables or files could not be evaluated", failures)
+	}
 
 	return nil
 }
+
+func (c *evaluateCommandConfig) writeChart(ctx context.Context, formatter format.Formatter, tables []cql.Schema) error {
+	chart, err := partitionChart(ctx, formatter, c.thresholds, c.chartMetric, chartTables(tables, c.rows))
+	if err != nil {
+		return fmt.Errorf("unable to create chart, err: %w", err)
+	}
+
+	f, err := os.Create(c.chartPath)
+	if err != nil {
+		return fmt.Errorf("unable to create chart file, err: %w", err)
+	}
+	defer f.Close()
+
+	if err := chart.WriteSVG(f); err != nil {
+		return fmt.Errorf("unable to write chart, err: %w", err)
+	}
+
+	return f.Close()
+}
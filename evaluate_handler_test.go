@@ -0,0 +1,248 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"rischmann.fr/cassandra-partition-calculator/lint"
+)
+
+func doEvaluate(t testing.TB, c *serveCommandConfig, form url.Values, htmx bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if htmx {
+		req.Header.Set("HX-Request", "true")
+	}
+
+	rec := httptest.NewRecorder()
+	c.evaluateHandler(rec, req)
+
+	return rec
+}
+
+func TestEvaluateHandlerFullPage(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	form := url.Values{
+		"schema":           {"CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));"},
+		"rows":             {"1000"},
+		"size::event_data": {"100"},
+	}
+
+	rec := doEvaluate(t, c, form, false)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	body := rec.Body.String()
+	require.Contains(t, body, "<html")
+	require.Contains(t, body, "<title>"+pageTitle+"</title>")
+	require.Contains(t, body, `value="1000"`)
+	require.Contains(t, body, `name="size::event_data" value="100"`)
+	require.Contains(t, body, "Partition size")
+}
+
+func TestEvaluateHandlerUnits(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	form := url.Values{
+		"schema":           {"CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));"},
+		"rows":             {"100000"},
+		"size::event_data": {"100"},
+		"units":            {"si"},
+	}
+
+	rec := doEvaluate(t, c, form, false)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, "13,200,032 bytes (13 MB)")
+	require.Contains(t, body, `<option value="si" selected>`)
+
+	form.Set("units", "KiB")
+
+	rec = doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "13,200,032 bytes (12,890.66 KiB)")
+
+	form.Set("units", "furlong")
+
+	rec = doEvaluate(t, c, form, true)
+	require.Contains(t, rec.Body.String(), `field &#34;units&#34; is invalid`)
+}
+
+func TestEvaluateHandlerFullPageErrors(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	form := url.Values{
+		"schema": {"CREATE TABLE events(user_id uuid PRIMARY KEY, name text STATIC);"},
+		"rows":   {"10"},
+	}
+
+	rec := doEvaluate(t, c, form, false)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, "<html")
+	require.Contains(t, body, `class="error-message"`)
+	// The submitted schema is kept in the form
+	require.Contains(t, body, "name text STATIC")
+	require.NotContains(t, body, "Partition size")
+}
+
+func TestEvaluateHandlerHTMX(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	form := url.Values{
+		"schema": {"CREATE TABLE events(user_id uuid PRIMARY KEY, name text);"},
+		"rows":   {"10"},
+	}
+
+	rec := doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.NotContains(t, body, "<html")
+	require.Contains(t, body, `id="columns"`)
+	require.Contains(t, body, `id="estimation"`)
+}
+
+func TestEvaluateHandlerKeepsSizeEstimatesOnErrors(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	// The schema is incomplete, as it is while it's being typed
+	form := url.Values{
+		"schema":           {"CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id)"},
+		"rows":             {"1000"},
+		"size::event_data": {"100"},
+	}
+
+	rec := doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, `class="error-message"`)
+	require.Contains(t, body, `<input type="hidden" name="size::event_data" value="100">`)
+
+	// Once the schema is fixed the size estimate is used again
+	form.Set("schema", form.Get("schema")+");")
+
+	rec = doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body = rec.Body.String()
+	require.NotContains(t, body, `class="error-message"`)
+	require.Contains(t, body, `id="size-event_data" name="size::event_data" value="100"`)
+	require.Contains(t, body, `hx-preserve="true"`)
+}
+
+func TestEvaluateHandlerIgnoresUnknownColumns(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	// event_payload was renamed event_data in the schema
+	form := url.Values{
+		"schema":              {"CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));"},
+		"rows":                {"1000"},
+		"size::event_payload": {"100"},
+	}
+
+	rec := doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.NotContains(t, body, `class="error-message"`)
+	require.NotContains(t, body, "event_payload")
+}
+
+func TestEvaluateHandlerIgnoresFixedSizeColumns(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	// The type of name was text when its size was estimated
+	form := url.Values{
+		"schema":     {"CREATE TABLE users(user_id uuid PRIMARY KEY, name int);"},
+		"rows":       {"1000"},
+		"size::name": {"30"},
+	}
+
+	rec := doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.NotContains(t, body, `class="error-message"`)
+	require.NotContains(t, body, `name="size::name"`)
+
+	// The estimate is kept while the schema is incomplete, it must not fail once it's complete
+	form.Set("schema", "CREATE TABLE users(user_id uuid PRIMARY KEY, name int")
+
+	rec = doEvaluate(t, c, form, true)
+	require.Contains(t, rec.Body.String(), `<input type="hidden" name="size::name" value="30">`)
+
+	form.Set("schema", form.Get("schema")+");")
+
+	rec = doEvaluate(t, c, form, true)
+	require.NotContains(t, rec.Body.String(), `class="error-message"`)
+}
+
+func TestEvaluateHandlerSchemaErrors(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	form := url.Values{
+		"schema": {"CREATE TABLE events(\n\tuser_id uuid,\n\tPRIMARY KEY (id)\n);"},
+		"rows":   {"10"},
+	}
+
+	rec := doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, `class="schema-error" hidden data-message="invalid column &#34;id&#34; in primary key" data-line="3" data-column="15" data-end-line="3" data-end-column="17"`)
+
+	// Violations only have a start position
+	form.Set("schema", "CREATE TABLE events(\n\tuser_id uuid PRIMARY KEY,\n\tname text STATIC\n);")
+
+	rec = doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body = rec.Body.String()
+	require.Contains(t, body, `data-line="3" data-column="2" data-end-line="0" data-end-column="0"`)
+}
+
+func TestIndexLintRules(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+	c.lintConfig.Disabled["too-many-columns"] = true
+
+	rec := httptest.NewRecorder()
+	c.indexHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, `<details class="lint-rules">`)
+	for _, rule := range lint.Rules {
+		require.Contains(t, body, `<span class="lint-rule">`+rule.Name+`</span>`)
+		require.Contains(t, body, `<td>`+rule.Description+`</td>`)
+	}
+	require.Contains(t, body, `<td class="lint-finding-warning">warning</td>`)
+	require.Contains(t, body, `<span class="lint-rule">too-many-columns</span> (disabled)`)
+}
+
+func TestEvaluateHandlerNegativeRows(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	form := url.Values{
+		"schema": {"CREATE TABLE events(user_id uuid PRIMARY KEY, name text);"},
+		"rows":   {"-10"},
+	}
+
+	_, err := c.parseEvaluateForm(form)
+	require.ErrorIs(t, err, errNegativeValue)
+
+	// Like the API
+	rec := doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `field &#34;rows&#34; is invalid because of error: value can&#39;t be negative`)
+	require.NotContains(t, rec.Body.String(), "Partition size")
+}
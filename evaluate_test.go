@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/stretchr/testify/require"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+func parseEvaluateCommand(t *testing.T, args ...string) (*evaluateCommandConfig, error) {
+	t.Helper()
+
+	rootCfg, rootCmd := newRootCommand()
+	evaluateCfg, evaluateCmd := newEvaluateCommandConfig(rootCfg)
+	rootCmd.Subcommands = []*ffcli.Command{evaluateCmd}
+
+	return evaluateCfg, rootCmd.Parse(args)
+}
+
+func TestEvaluateCommandFlags(t *testing.T) {
+	c, err := parseEvaluateCommand(t, "evaluate", "schema.cql")
+	require.NoError(t, err)
+	require.Equal(t, int64(100000), c.rows)
+	require.Empty(t, c.sizeEstimates)
+	require.Equal(t, cassandra.DefaultThresholds(), c.thresholds)
+
+	c, err = parseEvaluateCommand(t, "evaluate", "--rows", "42", "--size", "name=20", "--size", "events.data=100", "--max-partition-size", "1000", "--max-partition-values", "10", "schema.cql")
+	require.NoError(t, err)
+	require.Equal(t, int64(42), c.rows)
+	require.Equal(t, cassandra.Thresholds{MaxPartitionBytes: 1000, MaxPartitionValues: 10}, c.thresholds)
+	require.Equal(t, []sizeEstimate{
+		{column: "name", size: 20},
+		{table: "events", column: "data", size: 100},
+	}, c.sizeEstimates)
+
+	testCases := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"rows", []string{"--rows", "foo"}, `invalid value "foo" for flag -rows`},
+		{"size-without-value", []string{"--size", "name"}, `invalid size estimate "name", expected [table.]column=bytes`},
+		{"size-not-a-number", []string{"--size", "name=foo"}, `invalid size estimate "name=foo"`},
+		{"size-negative", []string{"--size", "name=-1"}, `invalid size estimate "name=-1", err: value can't be negative`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseEvaluateCommand(t, append(append([]string{"evaluate"}, tc.args...), "schema.cql")...)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	// The number of rows is checked before evaluating the inputs
+	c, err = parseEvaluateCommand(t, "evaluate", "--rows", "-5", "schema.cql")
+	require.NoError(t, err)
+	require.ErrorIs(t, c.Exec(context.Background(), []string{"schema.cql"}), errNegativeValue)
+}
+
+func TestReadEvaluateInputs(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"users.cql":              "CREATE TABLE users(id uuid PRIMARY KEY);",
+		"README.md":              "# Schemas",
+		"events/events.cql":      "CREATE TABLE events(id uuid PRIMARY KEY);",
+		"events/old/archive.cql": "CREATE TABLE archive(id uuid PRIMARY KEY);",
+		"events/notes.txt":       "not a schema",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	}
+
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	testCases := []struct {
+		name  string
+		args  []string
+		stdin string
+		exp   []evaluateInput
+		err   string
+	}{
+		{
+			name: "file",
+			args: []string{path("users.cql")},
+			exp:  []evaluateInput{{path("users.cql"), files["users.cql"]}},
+		},
+		{
+			name: "directory",
+			args: []string{path("events")},
+			exp: []evaluateInput{
+				{path("events/events.cql"), files["events/events.cql"]},
+				{path("events/old/archive.cql"), files["events/old/archive.cql"]},
+			},
+		},
+		{
+			name:  "stdin",
+			args:  []string{"-"},
+			stdin: "CREATE TABLE stdin(id uuid PRIMARY KEY);",
+			exp:   []evaluateInput{{"<stdin>", "CREATE TABLE stdin(id uuid PRIMARY KEY);"}},
+		},
+		{
+			name:  "mixed",
+			args:  []string{"-", path("users.cql")},
+			stdin: "CREATE TABLE stdin(id uuid PRIMARY KEY);",
+			exp: []evaluateInput{
+				{"<stdin>", "CREATE TABLE stdin(id uuid PRIMARY KEY);"},
+				{path("users.cql"), files["users.cql"]},
+			},
+		},
+		{
+			name:  "stdin-twice",
+			args:  []string{"-", path("users.cql"), "-"},
+			stdin: "CREATE TABLE stdin(id uuid PRIMARY KEY);",
+			err:   "stdin can only be read once",
+		},
+		{
+			name: "not-found",
+			args: []string{path("foo.cql")},
+			err:  "unable to stat input",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inputs, err := readEvaluateInputs(tc.args, strings.NewReader(tc.stdin))
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, inputs)
+		})
+	}
+}
+
+func TestApplySizeEstimates(t *testing.T) {
+	tables, _, err := cql.ParseDescribe(`
+CREATE TABLE users(user_id uuid PRIMARY KEY, name text, age int);
+CREATE TABLE events(user_id uuid, event_id timeuuid, name text, data blob, PRIMARY KEY (user_id, event_id));
+`)
+	require.NoError(t, err)
+	users, events := tables[0], tables[1]
+
+	testCases := []struct {
+		name      string
+		estimates []sizeEstimate
+		schema    cql.Schema
+		column    string
+		exp       int
+	}{
+		{"every-table", []sizeEstimate{{column: "name", size: 20}}, events, "name", 20},
+		{"same-table", []sizeEstimate{{table: "events", column: "data", size: 100}}, events, "data", 100},
+		{"other-table", []sizeEstimate{{table: "events", column: "name", size: 30}}, users, "name", columnSize(t, users, "name")},
+		{"last-wins", []sizeEstimate{{column: "name", size: 20}, {table: "users", column: "name", size: 40}}, users, "name", 40},
+		{"fixed-size", []sizeEstimate{{column: "age", size: 100}}, users, "age", 4},
+		{"unknown-column", []sizeEstimate{{column: "foo", size: 100}}, users, "name", columnSize(t, users, "name")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &evaluateCommandConfig{sizeEstimates: tc.estimates}
+
+			schema := c.applySizeEstimates(tc.schema.Clone())
+			require.Equal(t, tc.exp, columnSize(t, schema, tc.column))
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !release
+
+package examples
+
+import "os"
+
+func init() {
+	FS = os.DirFS("examples")
+}
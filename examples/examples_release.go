@@ -0,0 +1,12 @@
+//go:build release
+
+package examples
+
+import "embed"
+
+//go:embed *.cql
+var embedded embed.FS
+
+func init() {
+	FS = embedded
+}
@@ -0,0 +1,126 @@
+// Package examples provides the gallery of example schemas.
+//
+// An example is a .cql file whose leading comment lines are its title and description.
+package examples
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+// FS contains the built-in examples either from:
+// * the local file system in non-release builds
+// * a embedded file system in release builds
+var FS fs.FS
+
+type Example struct {
+	// Name identifies the example, it's the file name without the extension.
+	Name        string
+	Title       string
+	Description string
+	Schema      string
+}
+
+// parse parses the content of an example file.
+func parse(name string, data string) (Example, error) {
+	res := Example{
+		Name:  name,
+		Title: name,
+	}
+
+	// The leading comment lines are the title and the description
+
+	var description []string
+
+	lines := strings.Split(data, "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		if i == 0 {
+			res.Title = line
+		} else {
+			description = append(description, line)
+		}
+	}
+
+	res.Description = strings.Join(description, " ")
+	res.Schema = strings.TrimSpace(strings.Join(lines[i:], "\n"))
+
+	// The schema must be usable
+
+	tables, _, err := cql.ParseDescribe(res.Schema)
+	if err != nil {
+		return res, err
+	}
+	if len(tables) == 0 {
+		return res, fmt.Errorf("no CREATE TABLE statement found")
+	}
+
+	return res, nil
+}
+
+// Load reads all the examples in the root directory of fsys, sorted by name.
+func Load(fsys fs.FS) ([]Example, error) {
+	paths, err := fs.Glob(fsys, "*.cql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	res := make([]Example, 0, len(paths))
+	for _, p := range paths {
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read example %q, err: %w", p, err)
+		}
+
+		example, err := parse(strings.TrimSuffix(path.Base(p), ".cql"), string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid example %q, err: %w", p, err)
+		}
+
+		res = append(res, example)
+	}
+
+	return res, nil
+}
+
+// Merge returns the examples of base followed by the examples of extra.
+// An example of extra replaces the example of base with the same name.
+func Merge(base, extra []Example) []Example {
+	res := make([]Example, 0, len(base)+len(extra))
+	res = append(res, base...)
+
+outer:
+	for _, example := range extra {
+		for i := range res {
+			if res[i].Name == example.Name {
+				res[i] = example
+				continue outer
+			}
+		}
+		res = append(res, example)
+	}
+
+	return res
+}
+
+// Find returns the example named name.
+func Find(examples []Example, name string) (Example, bool) {
+	for _, example := range examples {
+		if example.Name == name {
+			return example, true
+		}
+	}
+	return Example{}, false
+}
@@ -0,0 +1,54 @@
+package examples
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBuiltins(t *testing.T) {
+	examples, err := Load(os.DirFS("."))
+	require.NoError(t, err)
+	require.NotEmpty(t, examples)
+
+	for _, example := range examples {
+		require.NotEmpty(t, example.Title, example.Name)
+		require.NotEmpty(t, example.Description, example.Name)
+		require.NotContains(t, example.Schema, "--", example.Name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"b.cql":  {Data: []byte("-- Users\n-- One partition\n-- per user.\nCREATE TABLE users(id uuid PRIMARY KEY);\n")},
+		"a.cql":  {Data: []byte("CREATE TABLE a(id int PRIMARY KEY);")},
+		"README": {Data: []byte("not an example")},
+	}
+
+	examples, err := Load(fsys)
+	require.NoError(t, err)
+	require.Equal(t, []Example{
+		{Name: "a", Title: "a", Schema: "CREATE TABLE a(id int PRIMARY KEY);"},
+		{Name: "b", Title: "Users", Description: "One partition per user.", Schema: "CREATE TABLE users(id uuid PRIMARY KEY);"},
+	}, examples)
+
+	_, err = Load(fstest.MapFS{"bad.cql": {Data: []byte("-- Bad\nCREATE INDEX foo ON bar (baz);")}})
+	require.ErrorContains(t, err, `invalid example "bad.cql"`)
+}
+
+func TestMerge(t *testing.T) {
+	base := []Example{{Name: "a", Title: "A"}, {Name: "b", Title: "B"}}
+	extra := []Example{{Name: "c", Title: "C"}, {Name: "a", Title: "Our A"}}
+
+	merged := Merge(base, extra)
+	require.Equal(t, []Example{{Name: "a", Title: "Our A"}, {Name: "b", Title: "B"}, {Name: "c", Title: "C"}}, merged)
+
+	example, ok := Find(merged, "c")
+	require.True(t, ok)
+	require.Equal(t, "C", example.Title)
+
+	_, ok = Find(merged, "d")
+	require.False(t, ok)
+}
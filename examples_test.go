@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexHandlerExamples(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+	handler := c.routes()
+
+	// The first example is the default
+	rec := doRequest(t, handler, http.MethodGet, "/", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "CREATE TABLE events(")
+	require.Contains(t, rec.Body.String(), `<option value="user_profile" title=`)
+
+	rec = doRequest(t, handler, http.MethodGet, "/?example=messaging", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "CREATE TABLE messages(")
+	require.Contains(t, rec.Body.String(), `<option value="messaging" title="Messages of a conversation, the most recent first." selected>`)
+
+	rec = doRequest(t, handler, http.MethodGet, "/?example=foo", "", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Contains(t, rec.Body.String(), `unknown example &#34;foo&#34;`)
+}
+
+func TestExamplesHandler(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+	handler := c.routes()
+
+	req := httptest.NewRequest(http.MethodGet, "/examples?example=iot_buckets", nil)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `<textarea id="schema-input" name="schema"`)
+	require.Contains(t, rec.Body.String(), "CREATE TABLE sensor_readings(")
+
+	// Without htmx the page is loaded with the example
+	rec = doRequest(t, handler, http.MethodGet, "/examples?example=iot_buckets", "", "")
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Equal(t, "/?example=iot_buckets", rec.Header().Get("Location"))
+}
+
+func TestLoadExamplesDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "audit.cql"), []byte("-- Audit log\nCREATE TABLE audit(day date, id timeuuid, PRIMARY KEY (day, id));"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "messaging.cql"), []byte("-- Our messaging\nCREATE TABLE our_messages(id uuid PRIMARY KEY);"), 0o644))
+
+	c := newTestServeCommandConfig(t)
+	builtins := len(c.examples)
+
+	c.examplesDirs = []string{dir}
+	require.NoError(t, c.loadExamples())
+	require.Len(t, c.examples, builtins+1)
+
+	rec := doRequest(t, c.routes(), http.MethodGet, "/?example=messaging", "", "")
+	require.Contains(t, rec.Body.String(), "CREATE TABLE our_messages(")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.cql"), []byte("CREATE"), 0o644))
+	require.Error(t, c.loadExamples())
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+type fmtCommandConfig struct {
+	root *rootCommandConfig
+
+	check   bool
+	inPlace bool
+}
+
+func newFmtCommandConfig(root *rootCommandConfig) *ffcli.Command {
+	cfg := &fmtCommandConfig{
+		root: root,
+	}
+
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.BoolVar(&cfg.check, "check", false, "Don't write anything, list the files not formatted and fail if there are any")
+	fs.BoolVar(&cfg.inPlace, "w", false, "Write the formatted schema to the file instead of stdout")
+
+	return &ffcli.Command{
+		Name:       "fmt",
+		ShortUsage: "fmt [flags] <schema file>...",
+		ShortHelp:  `format CQL schema files`,
+		FlagSet:    fs,
+		Exec:       cfg.Exec,
+	}
+}
+
+func (c *fmtCommandConfig) Exec(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return flag.ErrHelp
+	}
+
+	unformatted := 0
+
+	for _, path := range args {
+		input, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read input file, err: %w", err)
+		}
+
+		schemas, err := cql.ParseSchemas(string(input))
+		if err != nil {
+			return fmt.Errorf("unable to parse schema %q, err: %w", path, err)
+		}
+
+		formatted := cql.FormatSchemas(schemas)
+
+		switch {
+		case c.check:
+			if formatted != string(input) {
+				fmt.Println(path)
+				unformatted++
+			}
+
+		case c.inPlace:
+			if formatted == string(input) {
+				continue
+			}
+
+			fi, err := os.Stat(path)
+			if err != nil {
+				return fmt.Errorf("unable to stat input file, err: %w", err)
+			}
+			if err := os.WriteFile(path, []byte(formatted), fi.Mode().Perm()); err != nil {
+				return fmt.Errorf("unable to write formatted file, err: %w", err)
+			}
+
+		default:
+			fmt.Print(formatted)
+		}
+	}
+
+	if unformatted > 0 {
+		return fmt.Errorf("%d files are not formatted", unformatted)
+	}
+
+	return nil
+}
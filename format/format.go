@@ -0,0 +1,205 @@
+// Package format formats the numbers and sizes displayed in the web UI, the reports, the CLI and the API.
+//
+// Numbers are formatted according to the language of a printer, for example 1,234 in English and 1.234 in German.
+// Sizes are formatted with a unit system chosen by the user, see Units.
+package format
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Unit is a unit of size.
+type Unit struct {
+	Symbol string
+	// Size is the number of bytes in the unit
+	Size int64
+}
+
+var (
+	Byte = Unit{"B", 1}
+
+	Kilobyte = Unit{"kB", 1000}
+	Megabyte = Unit{"MB", 1000 * 1000}
+	Gigabyte = Unit{"GB", 1000 * 1000 * 1000}
+	Terabyte = Unit{"TB", 1000 * 1000 * 1000 * 1000}
+	Petabyte = Unit{"PB", 1000 * 1000 * 1000 * 1000 * 1000}
+
+	Kibibyte = Unit{"KiB", 1 << 10}
+	Mebibyte = Unit{"MiB", 1 << 20}
+	Gibibyte = Unit{"GiB", 1 << 30}
+	Tebibyte = Unit{"TiB", 1 << 40}
+	Pebibyte = Unit{"PiB", 1 << 50}
+)
+
+var (
+	siUnits  = []Unit{Byte, Kilobyte, Megabyte, Gigabyte, Terabyte, Petabyte}
+	iecUnits = []Unit{Byte, Kibibyte, Mebibyte, Gibibyte, Tebibyte, Pebibyte}
+)
+
+// Units is how sizes are formatted: either with the most appropriate unit of a system, or always with the same unit.
+//
+// The zero value is IEC.
+type Units struct {
+	system string
+	fixed  Unit
+}
+
+var (
+	// IEC formats sizes with the most appropriate power of 1024: KiB, MiB, etc.
+	IEC = Units{}
+	// SI formats sizes with the most appropriate power of 1000: kB, MB, etc.
+	SI = Units{system: "si"}
+)
+
+// Fixed formats every size with unit.
+func Fixed(unit Unit) Units {
+	return Units{fixed: unit}
+}
+
+// AllUnits are every supported units, in the order they are presented to the users.
+var AllUnits = func() []Units {
+	res := []Units{IEC, SI}
+	for _, unit := range iecUnits {
+		res = append(res, Fixed(unit))
+	}
+	for _, unit := range siUnits[1:] {
+		res = append(res, Fixed(unit))
+	}
+	return res
+}()
+
+// ParseUnits parses "iec", "si" or the symbol of a unit like "MiB", case insensitively.
+// An empty string is IEC.
+func ParseUnits(s string) (Units, error) {
+	s = strings.TrimSpace(s)
+
+	switch {
+	case s == "":
+		return IEC, nil
+	case strings.EqualFold(s, "iec"):
+		return IEC, nil
+	case strings.EqualFold(s, "si"):
+		return SI, nil
+	}
+
+	for _, units := range AllUnits {
+		if units.fixed != (Unit{}) && strings.EqualFold(s, units.fixed.Symbol) {
+			return units, nil
+		}
+	}
+
+	return Units{}, fmt.Errorf("invalid units %q, expected iec, si or a unit like MiB", s)
+}
+
+// String returns the value parsed by ParseUnits.
+func (u Units) String() string {
+	if u.fixed != (Unit{}) {
+		return u.fixed.Symbol
+	}
+	if u.system == "" {
+		return "iec"
+	}
+	return u.system
+}
+
+func (u Units) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *Units) UnmarshalText(data []byte) error {
+	units, err := ParseUnits(string(data))
+	if err != nil {
+		return err
+	}
+	*u = units
+	return nil
+}
+
+// unit returns the unit to format n with.
+func (u Units) unit(n int64) Unit {
+	if u.fixed != (Unit{}) {
+		return u.fixed
+	}
+
+	units := iecUnits
+	if u.system == SI.system {
+		units = siUnits
+	}
+
+	if n < 0 {
+		n = -n
+	}
+
+	res := units[0]
+	for _, unit := range units[1:] {
+		if n < unit.Size {
+			break
+		}
+		res = unit
+	}
+	return res
+}
+
+// Formatter formats numbers and sizes.
+type Formatter struct {
+	printer *message.Printer
+	units   Units
+}
+
+// New returns a formatter formatting the numbers in the language of printer and the sizes with units.
+func New(printer *message.Printer, units Units) Formatter {
+	return Formatter{
+		printer: printer,
+		units:   units,
+	}
+}
+
+// Units returns the units of the sizes.
+func (f Formatter) Units() Units {
+	return f.units
+}
+
+// Number formats an integer, for example 1,234,567.
+func (f Formatter) Number(n int64) string {
+	return f.printer.Sprint(number.Decimal(n))
+}
+
+// Bytes formats a size in bytes with its unit, for example 1.5 MiB.
+//
+// With a unit system, sizes lower than 10 units have one fraction digit and the others none.
+// With a fixed unit, sizes have up to two fraction digits, more if they would be rounded to zero.
+func (f Formatter) Bytes(n int64) string {
+	unit := f.units.unit(n)
+	if unit.Size == 1 {
+		return f.Number(n) + " " + unit.Symbol
+	}
+
+	value := float64(n) / float64(unit.Size)
+
+	var options []number.Option
+	switch {
+	case f.units.fixed == (Unit{}) && math.Abs(value) < 10:
+		options = []number.Option{number.MinFractionDigits(1), number.MaxFractionDigits(1)}
+	case f.units.fixed == (Unit{}):
+		options = []number.Option{number.MaxFractionDigits(0)}
+	default:
+		digits := 2
+		for n != 0 && digits < 6 && math.Abs(value) < math.Pow(10, -float64(digits))/2 {
+			digits++
+		}
+		options = []number.Option{number.MaxFractionDigits(digits)}
+	}
+
+	return f.printer.Sprint(number.Decimal(value, options...)) + " " + unit.Symbol
+}
+
+// DetailedBytes formats a size with both its exact number of bytes and its unit, for example 1,572,864 bytes (1.5 MiB).
+// The text is translated if the printer has a catalog with the message "%s bytes (%s)".
+func (f Formatter) DetailedBytes(n int64) string {
+	return f.printer.Sprintf("%s bytes (%s)", f.Number(n), f.Bytes(n))
+}
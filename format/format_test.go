@@ -0,0 +1,86 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func TestParseUnits(t *testing.T) {
+	testCases := []struct {
+		input string
+		exp   Units
+	}{
+		{"", IEC},
+		{"iec", IEC},
+		{"SI", SI},
+		{"MiB", Fixed(Mebibyte)},
+		{"mib", Fixed(Mebibyte)},
+		{"kb", Fixed(Kilobyte)},
+		{" B ", Fixed(Byte)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			units, err := ParseUnits(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, units)
+		})
+	}
+
+	_, err := ParseUnits("furlong")
+	require.Error(t, err)
+}
+
+func TestUnitsText(t *testing.T) {
+	for _, units := range AllUnits {
+		data, err := units.MarshalText()
+		require.NoError(t, err)
+
+		var parsed Units
+		require.NoError(t, parsed.UnmarshalText(data))
+		require.Equal(t, units, parsed)
+	}
+
+	require.Equal(t, "iec", Units{}.String())
+	require.Equal(t, IEC, Units{})
+}
+
+func TestFormatter(t *testing.T) {
+	english := message.NewPrinter(language.English)
+
+	testCases := []struct {
+		name  string
+		units Units
+		n     int64
+		exp   string
+	}{
+		{"iec-bytes", IEC, 100, "100 B"},
+		{"iec-small", IEC, 1024, "1.0 KiB"},
+		{"iec-large", IEC, 132032, "129 KiB"},
+		{"iec-negative", IEC, -1536, "-1.5 KiB"},
+		{"si-small", SI, 1500, "1.5 kB"},
+		{"si-large", SI, 2_500_000_000, "2.5 GB"},
+		{"fixed-mib", Fixed(Mebibyte), 5 << 30, "5,120 MiB"},
+		{"fixed-fraction", Fixed(Mebibyte), 1 << 19, "0.5 MiB"},
+		{"fixed-tiny", Fixed(Mebibyte), 100, "0.0001 MiB"},
+		{"fixed-bytes", Fixed(Byte), 1 << 20, "1,048,576 B"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, New(english, tc.units).Bytes(tc.n))
+		})
+	}
+}
+
+func TestFormatterLanguage(t *testing.T) {
+	german := New(message.NewPrinter(language.German), IEC)
+	require.Equal(t, "1.234.567", german.Number(1234567))
+	require.Equal(t, "1,5 KiB", german.Bytes(1536))
+
+	english := New(message.NewPrinter(language.English), SI)
+	require.Equal(t, "1,234,567 bytes (1.2 MB)", english.DetailedBytes(1234567))
+}
@@ -0,0 +1,132 @@
+package i18n
+
+var german = map[string]string{
+	// Pages
+	"Cassandra Partition Calculator": "Cassandra-Partitionsrechner",
+	"Calculator":                     "Rechner",
+	"Compare":                        "Vergleichen",
+	"Language":                       "Sprache",
+
+	// Form
+	"Write your CQL schema here": "Schreiben Sie Ihr CQL-Schema hier",
+	"Start from an example":      "Mit einem Beispiel beginnen",
+	"Load":                       "Laden",
+	"Copy your table schema below to start estimating its size": "Kopieren Sie Ihr Tabellenschema unten, um seine Größe abzuschätzen",
+	"Estimated number of rows":                                  "Geschätzte Anzahl der Zeilen",
+	"Units":                                                     "Einheiten",
+	"Automatic (KiB, MiB, GiB)":                                 "Automatisch (KiB, MiB, GiB)",
+	"Automatic (kB, MB, GB)":                                    "Automatisch (kB, MB, GB)",
+	"Submit":                                                    "Absenden",
+	"Scenario":                                                  "Szenario",
+	"Name":                                                      "Name",
+	"Notes about this design":                                   "Notizen zu diesem Entwurf",
+	"Save scenario":                                             "Szenario speichern",
+	"Saved scenarios":                                           "Gespeicherte Szenarien",
+
+	// Compare and scenarios
+	"Compare the partition size of several designs, the first one is the baseline": "Vergleichen Sie die Partitionsgröße mehrerer Entwürfe, der erste ist die Referenz",
+	"Sizes":                      "Größen",
+	"column=bytes, column=bytes": "Spalte=Bytes, Spalte=Bytes",
+	"Design %d":                  "Entwurf %d",
+	"Scenario %s":                "Szenario %s",
+	"New calculation":            "Neue Berechnung",
+	"No scenario saved yet.":     "Noch kein Szenario gespeichert.",
+	"Notes":                      "Notizen",
+	"Updated":                    "Aktualisiert",
+	"Clone":                      "Duplizieren",
+	"Delete":                     "Löschen",
+	"Compare selected scenarios": "Ausgewählte Szenarien vergleichen",
+
+	// Results
+	"Request ID:":                     "Anfrage-ID:",
+	"Column":                          "Spalte",
+	"Type":                            "Typ",
+	"Size":                            "Größe",
+	"Type your size estimation":       "Geben Sie Ihre Größenschätzung ein",
+	"Partition key":                   "Partitionsschlüssel",
+	"Clustering key":                  "Clustering-Schlüssel",
+	"Columns":                         "Spalten",
+	"Non partition key columns":       "Spalten außerhalb des Partitionsschlüssels",
+	"Partition values":                "Partitionswerte",
+	"Partition size":                  "Partitionsgröße",
+	"Tables":                          "Tabellen",
+	"Partition values of every table": "Partitionswerte aller Tabellen",
+	"Partition size of every table":   "Partitionsgröße aller Tabellen",
+	"Share this calculation":          "Diese Berechnung teilen",
+	"Permalink":                       "Permalink",
+	"Export the results":              "Ergebnisse exportieren",
+	"HTML report":                     "HTML-Bericht",
+	"%s bytes (%s)":                   "%s Bytes (%s)",
+	"Lint rules":                      "Lint-Regeln",
+	"Rule":                            "Regel",
+	"Severity":                        "Schweregrad",
+	"Description":                     "Beschreibung",
+	"disabled":                        "deaktiviert",
+
+	// Charts
+	"Recommended maximum": "Empfohlenes Maximum",
+	"Hard limit":          "Harte Grenze",
+	"%s rows":             "%s Zeilen",
+
+	// Comparison
+	"Rows":      "Zeilen",
+	"Values":    "Werte",
+	"Metadata":  "Metadaten",
+	"Rows data": "Zeilendaten",
+	"Warnings":  "Warnungen",
+
+	// Lint
+	"warning": "Warnung",
+	"error":   "Fehler",
+	"the partition key is a single column with a low cardinality type":                        "der Partitionsschlüssel ist eine einzelne Spalte mit einem Typ geringer Kardinalität",
+	"the table stores time based data but has no clustering key":                              "die Tabelle speichert zeitbasierte Daten, hat aber keinen Clustering-Schlüssel",
+	"a heavily written table has a non frozen collection column":                              "eine stark beschriebene Tabelle hat eine nicht eingefrorene Collection-Spalte",
+	"the table has more columns than the configured maximum":                                  "die Tabelle hat mehr Spalten als das konfigurierte Maximum",
+	"a blob column is part of the primary key":                                                "eine blob-Spalte ist Teil des Primärschlüssels",
+	"partition key is a single %s column, all rows will end up in very few partitions":        "der Partitionsschlüssel ist eine einzelne %s-Spalte, alle Zeilen landen in sehr wenigen Partitionen",
+	"table has a %s column but no clustering key, each new event overwrites the previous one": "die Tabelle hat eine %s-Spalte, aber keinen Clustering-Schlüssel, jedes neue Ereignis überschreibt das vorherige",
+	"non frozen collections create tombstones when overwritten, consider using frozen<>":      "nicht eingefrorene Collections erzeugen beim Überschreiben Tombstones, verwenden Sie besser frozen<>",
+	"table has %d columns, the maximum is %d":                                                 "die Tabelle hat %d Spalten, das Maximum ist %d",
+	"blob columns in the primary key are hard to query and have an unbounded size":            "blob-Spalten im Primärschlüssel sind schwer abzufragen und haben eine unbegrenzte Größe",
+	"a static column is defined on a table without clustering columns":                        "eine statische Spalte ist in einer Tabelle ohne Clustering-Spalten definiert",
+	"a column is defined more than once":                                                      "eine Spalte ist mehrfach definiert",
+	"static columns are only allowed on tables with clustering columns":                       "statische Spalten sind nur in Tabellen mit Clustering-Spalten erlaubt",
+	"column is defined more than once":                                                        "Spalte ist mehrfach definiert",
+
+	// Errors
+	"field %q is invalid because of error: %s": "Feld %q ist ungültig wegen des Fehlers: %s",
+	"field empty":                                     "Feld leer",
+	"no CREATE TABLE statement found":                 "keine CREATE TABLE-Anweisung gefunden",
+	"unknown column":                                  "unbekannte Spalte",
+	"column has a fixed size":                         "Spalte hat eine feste Größe",
+	"value can't be negative":                         "Wert darf nicht negativ sein",
+	"the form is larger than the limit of %s":         "das Formular überschreitet die Grenze von %s",
+	"unable to parse form, err: %s":                   "Formular kann nicht gelesen werden, Fehler: %s",
+	"table %q: %s":                                    "Tabelle %q: %s",
+	"table %q is defined more than once":              "Tabelle %q ist mehrfach definiert",
+	"invalid size estimate %q, expected column=bytes": "ungültige Größenschätzung %q, erwartet Spalte=Bytes",
+	"invalid size estimate %q, err: %s":               "ungültige Größenschätzung %q, Fehler: %s",
+	"invalid scenario id %q":                          "ungültige Szenario-ID %q",
+	"saved scenarios are disabled":                    "gespeicherte Szenarien sind deaktiviert",
+	"scenario not found":                              "Szenario nicht gefunden",
+	"schemas with several tables can't be compared, open the link to evaluate the tables": "Schemas mit mehreren Tabellen können nicht verglichen werden, öffnen Sie den Link, um die Tabellen auszuwerten",
+	"invalid permalink":  "ungültiger Permalink",
+	"unknown example %q": "unbekanntes Beispiel %q",
+	"state is too large": "der Zustand ist zu groß",
+
+	// Schema errors
+	"end of file":                                         "Dateiende",
+	"unterminated string literal":                         "nicht abgeschlossenes Zeichenkettenliteral",
+	"no more whitespace to be found, err: %s":             "kein Leerraum mehr zu finden, Fehler: %s",
+	"expected %q, got %q":                                 "%q erwartet, %q erhalten",
+	"invalid type":                                        "ungültiger Typ",
+	"multiple primary keys defined":                       "mehrere Primärschlüssel definiert",
+	"invalid column %q in primary key":                    "ungültige Spalte %q im Primärschlüssel",
+	"missing option value":                                "fehlender Optionswert",
+	"column %q is defined more than once":                 "Spalte %q ist mehrfach definiert",
+	"table %q has no PRIMARY KEY":                         "Tabelle %q hat keinen PRIMARY KEY",
+	"PRIMARY KEY is already declared inline on column %q": "PRIMARY KEY ist bereits an der Spalte %q deklariert",
+	"static column %q is not allowed on a table without clustering columns": "statische Spalte %q ist in einer Tabelle ohne Clustering-Spalten nicht erlaubt",
+	"primary key column %q has a non frozen collection type %q":             "Primärschlüsselspalte %q hat einen nicht eingefrorenen Collection-Typ %q",
+	"column %q can't be mixed with counter column %q":                       "Spalte %q kann nicht mit der counter-Spalte %q gemischt werden",
+}
@@ -0,0 +1,132 @@
+package i18n
+
+var french = map[string]string{
+	// Pages
+	"Cassandra Partition Calculator": "Calculateur de partitions Cassandra",
+	"Calculator":                     "Calculateur",
+	"Compare":                        "Comparer",
+	"Language":                       "Langue",
+
+	// Form
+	"Write your CQL schema here": "Écrivez votre schéma CQL ici",
+	"Start from an example":      "Partir d'un exemple",
+	"Load":                       "Charger",
+	"Copy your table schema below to start estimating its size": "Copiez le schéma de votre table ci-dessous pour estimer sa taille",
+	"Estimated number of rows":                                  "Nombre de lignes estimé",
+	"Units":                                                     "Unités",
+	"Automatic (KiB, MiB, GiB)":                                 "Automatique (KiB, MiB, GiB)",
+	"Automatic (kB, MB, GB)":                                    "Automatique (kB, MB, GB)",
+	"Submit":                                                    "Valider",
+	"Scenario":                                                  "Scénario",
+	"Name":                                                      "Nom",
+	"Notes about this design":                                   "Notes sur cette conception",
+	"Save scenario":                                             "Enregistrer le scénario",
+	"Saved scenarios":                                           "Scénarios enregistrés",
+
+	// Compare and scenarios
+	"Compare the partition size of several designs, the first one is the baseline": "Comparez la taille des partitions de plusieurs conceptions, la première sert de référence",
+	"Sizes":                      "Tailles",
+	"column=bytes, column=bytes": "colonne=octets, colonne=octets",
+	"Design %d":                  "Conception %d",
+	"Scenario %s":                "Scénario %s",
+	"New calculation":            "Nouveau calcul",
+	"No scenario saved yet.":     "Aucun scénario enregistré pour l'instant.",
+	"Notes":                      "Notes",
+	"Updated":                    "Modifié",
+	"Clone":                      "Dupliquer",
+	"Delete":                     "Supprimer",
+	"Compare selected scenarios": "Comparer les scénarios sélectionnés",
+
+	// Results
+	"Request ID:":                     "ID de requête :",
+	"Column":                          "Colonne",
+	"Type":                            "Type",
+	"Size":                            "Taille",
+	"Type your size estimation":       "Saisissez votre estimation de taille",
+	"Partition key":                   "Clé de partition",
+	"Clustering key":                  "Clé de clustering",
+	"Columns":                         "Colonnes",
+	"Non partition key columns":       "Colonnes hors clé de partition",
+	"Partition values":                "Valeurs de la partition",
+	"Partition size":                  "Taille de la partition",
+	"Tables":                          "Tables",
+	"Partition values of every table": "Valeurs des partitions de toutes les tables",
+	"Partition size of every table":   "Taille des partitions de toutes les tables",
+	"Share this calculation":          "Partager ce calcul",
+	"Permalink":                       "Lien permanent",
+	"Export the results":              "Exporter les résultats",
+	"HTML report":                     "Rapport HTML",
+	"%s bytes (%s)":                   "%s octets (%s)",
+	"Lint rules":                      "Règles du linter",
+	"Rule":                            "Règle",
+	"Severity":                        "Gravité",
+	"Description":                     "Description",
+	"disabled":                        "désactivée",
+
+	// Charts
+	"Recommended maximum": "Maximum recommandé",
+	"Hard limit":          "Limite absolue",
+	"%s rows":             "%s lignes",
+
+	// Comparison
+	"Rows":      "Lignes",
+	"Values":    "Valeurs",
+	"Metadata":  "Métadonnées",
+	"Rows data": "Données des lignes",
+	"Warnings":  "Avertissements",
+
+	// Lint
+	"warning": "avertissement",
+	"error":   "erreur",
+	"the partition key is a single column with a low cardinality type":                        "la clé de partition est une seule colonne d'un type à faible cardinalité",
+	"the table stores time based data but has no clustering key":                              "la table stocke des données temporelles mais n'a pas de clé de clustering",
+	"a heavily written table has a non frozen collection column":                              "une table très sollicitée en écriture a une colonne de collection non frozen",
+	"the table has more columns than the configured maximum":                                  "la table a plus de colonnes que le maximum configuré",
+	"a blob column is part of the primary key":                                                "une colonne blob fait partie de la clé primaire",
+	"partition key is a single %s column, all rows will end up in very few partitions":        "la clé de partition est une seule colonne %s, toutes les lignes finiront dans très peu de partitions",
+	"table has a %s column but no clustering key, each new event overwrites the previous one": "la table a une colonne %s mais pas de clé de clustering, chaque nouvel événement écrase le précédent",
+	"non frozen collections create tombstones when overwritten, consider using frozen<>":      "les collections non frozen créent des tombstones quand elles sont écrasées, utilisez plutôt frozen<>",
+	"table has %d columns, the maximum is %d":                                                 "la table a %d colonnes, le maximum est %d",
+	"blob columns in the primary key are hard to query and have an unbounded size":            "les colonnes blob de la clé primaire sont difficiles à requêter et ont une taille non bornée",
+	"a static column is defined on a table without clustering columns":                        "une colonne statique est définie dans une table sans colonnes de clustering",
+	"a column is defined more than once":                                                      "une colonne est définie plusieurs fois",
+	"static columns are only allowed on tables with clustering columns":                       "les colonnes statiques ne sont autorisées que dans les tables avec des colonnes de clustering",
+	"column is defined more than once":                                                        "la colonne est définie plusieurs fois",
+
+	// Errors
+	"field %q is invalid because of error: %s": "le champ %q est invalide à cause de l'erreur : %s",
+	"field empty":                                     "champ vide",
+	"no CREATE TABLE statement found":                 "aucune instruction CREATE TABLE trouvée",
+	"unknown column":                                  "colonne inconnue",
+	"column has a fixed size":                         "la colonne a une taille fixe",
+	"value can't be negative":                         "la valeur ne peut pas être négative",
+	"the form is larger than the limit of %s":         "le formulaire dépasse la limite de %s",
+	"unable to parse form, err: %s":                   "impossible de lire le formulaire, erreur : %s",
+	"table %q: %s":                                    "table %q : %s",
+	"table %q is defined more than once":              "la table %q est définie plusieurs fois",
+	"invalid size estimate %q, expected column=bytes": "estimation de taille %q invalide, colonne=octets attendu",
+	"invalid size estimate %q, err: %s":               "estimation de taille %q invalide, erreur : %s",
+	"invalid scenario id %q":                          "identifiant de scénario %q invalide",
+	"saved scenarios are disabled":                    "les scénarios enregistrés sont désactivés",
+	"scenario not found":                              "scénario introuvable",
+	"schemas with several tables can't be compared, open the link to evaluate the tables": "les schémas avec plusieurs tables ne peuvent pas être comparés, ouvrez le lien pour évaluer les tables",
+	"invalid permalink":  "lien permanent invalide",
+	"unknown example %q": "exemple %q inconnu",
+	"state is too large": "l'état est trop grand",
+
+	// Schema errors
+	"end of file":                                         "fin du fichier",
+	"unterminated string literal":                         "chaîne de caractères non terminée",
+	"no more whitespace to be found, err: %s":             "plus d'espace à trouver, erreur : %s",
+	"expected %q, got %q":                                 "%q attendu, %q trouvé",
+	"invalid type":                                        "type invalide",
+	"multiple primary keys defined":                       "plusieurs clés primaires définies",
+	"invalid column %q in primary key":                    "colonne %q invalide dans la clé primaire",
+	"missing option value":                                "valeur d'option manquante",
+	"column %q is defined more than once":                 "la colonne %q est définie plusieurs fois",
+	"table %q has no PRIMARY KEY":                         "la table %q n'a pas de PRIMARY KEY",
+	"PRIMARY KEY is already declared inline on column %q": "la PRIMARY KEY est déjà déclarée sur la colonne %q",
+	"static column %q is not allowed on a table without clustering columns": "la colonne statique %q n'est pas autorisée dans une table sans colonnes de clustering",
+	"primary key column %q has a non frozen collection type %q":             "la colonne %q de la clé primaire a un type de collection non frozen %q",
+	"column %q can't be mixed with counter column %q":                       "la colonne %q ne peut pas être mélangée avec la colonne counter %q",
+}
@@ -0,0 +1,203 @@
+// Package i18n implements the localization of the web UI.
+//
+// The messages are identified by their English text, which is also used when there's no translation.
+// The translations of every supported language are in the catalog of the package.
+package i18n
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// Language is a language the UI is translated in.
+type Language struct {
+	Tag language.Tag
+	// Name is the name of the language in the language itself
+	Name string
+}
+
+// Languages are the supported languages, the first one is the default.
+var Languages = []Language{
+	{Tag: language.English, Name: "English"},
+	{Tag: language.French, Name: "Français"},
+	{Tag: language.German, Name: "Deutsch"},
+}
+
+var (
+	matcher = newMatcher()
+	cat     = newCatalog()
+)
+
+func newMatcher() language.Matcher {
+	tags := make([]language.Tag, 0, len(Languages))
+	for _, lang := range Languages {
+		tags = append(tags, lang.Tag)
+	}
+	return language.NewMatcher(tags)
+}
+
+func newCatalog() catalog.Catalog {
+	translations := map[language.Tag]map[string]string{
+		language.French: french,
+		language.German: german,
+	}
+
+	builder := catalog.NewBuilder(catalog.Fallback(language.English))
+	for tag, messages := range translations {
+		for key, msg := range messages {
+			if err := builder.SetString(tag, key, msg); err != nil {
+				panic(fmt.Errorf("invalid %s translation of %q, err: %w", tag, key, err))
+			}
+		}
+	}
+
+	return builder
+}
+
+// Match returns the supported language best matching the Accept-Language header value.
+func Match(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Languages[0].Tag
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Languages[0].Tag
+	}
+
+	return Languages[index].Tag
+}
+
+// Find returns the supported language with the tag s, for example "fr".
+func Find(s string) (language.Tag, bool) {
+	for _, lang := range Languages {
+		if lang.Tag.String() == s {
+			return lang.Tag, true
+		}
+	}
+	return language.Tag{}, false
+}
+
+// NewPrinter returns a printer translating the messages to tag.
+func NewPrinter(tag language.Tag) *message.Printer {
+	return message.NewPrinter(tag, message.Catalog(cat))
+}
+
+//
+// Context
+//
+
+type contextKey struct{}
+
+// WithLanguage returns a copy of ctx with the language the responses are translated in.
+func WithLanguage(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, contextKey{}, tag)
+}
+
+// LanguageOf returns the language of ctx, the default language if there's none.
+func LanguageOf(ctx context.Context) language.Tag {
+	if tag, ok := ctx.Value(contextKey{}).(language.Tag); ok {
+		return tag
+	}
+	return Languages[0].Tag
+}
+
+// Printer returns the printer of the language of ctx.
+func Printer(ctx context.Context) *message.Printer {
+	return NewPrinter(LanguageOf(ctx))
+}
+
+// T translates the message key to the language of ctx, the key is formatted with args like fmt.Sprintf.
+func T(ctx context.Context, key string, args ...any) string {
+	return Printer(ctx).Sprintf(key, args...)
+}
+
+//
+// Messages
+//
+
+// MessageFormatter is implemented by the messages of other packages which can be translated
+// without depending on this package.
+type MessageFormatter interface {
+	// MessageFormat returns the format of the message, which is its key, and its arguments.
+	MessageFormat() (format string, args []any)
+}
+
+// Message translates m to the language of ctx.
+func Message(ctx context.Context, m MessageFormatter) string {
+	return formatMessage(Printer(ctx), m)
+}
+
+func formatMessage(p *message.Printer, m MessageFormatter) string {
+	format, args := m.MessageFormat()
+	return p.Sprintf(format, localizeArgs(p, args)...)
+}
+
+// localizeArgs returns args with the errors replaced by their translated message.
+func localizeArgs(p *message.Printer, args []any) []any {
+	res := make([]any, len(args))
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			arg = ErrorMessage(p, err)
+		}
+		res[i] = arg
+	}
+	return res
+}
+
+//
+// Errors
+//
+
+// Localizer is implemented by the errors which can be translated.
+type Localizer interface {
+	Localize(p *message.Printer) string
+}
+
+// Error is an error whose message is translated, its format is the key of the message.
+type Error struct {
+	format string
+	args   []any
+}
+
+// NewError creates an error formatted like fmt.Sprintf.
+// The errors in args are translated too and can be found with errors.Is and errors.As.
+func NewError(format string, args ...any) *Error {
+	return &Error{format: format, args: args}
+}
+
+// Error returns the English message.
+func (e *Error) Error() string {
+	return fmt.Sprintf(e.format, e.args...)
+}
+
+func (e *Error) Unwrap() []error {
+	var res []error
+	for _, arg := range e.args {
+		if err, ok := arg.(error); ok {
+			res = append(res, err)
+		}
+	}
+	return res
+}
+
+func (e *Error) MessageFormat() (string, []any) {
+	return e.format, e.args
+}
+
+// ErrorMessage returns the message of err translated by p if it can be translated, the English message otherwise.
+func ErrorMessage(p *message.Printer, err error) string {
+	switch err := err.(type) {
+	case Localizer:
+		return err.Localize(p)
+	case MessageFormatter:
+		return formatMessage(p, err)
+	default:
+		return err.Error()
+	}
+}
@@ -0,0 +1,121 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+var verbRegexp = regexp.MustCompile(`%[a-z]`)
+
+func TestTranslations(t *testing.T) {
+	translations := map[string]map[string]string{
+		"fr": french,
+		"de": german,
+	}
+
+	for name, messages := range translations {
+		t.Run(name, func(t *testing.T) {
+			// Every language translates the same messages
+			for key := range french {
+				require.Contains(t, messages, key)
+			}
+			for key := range german {
+				require.Contains(t, messages, key)
+			}
+
+			// With the same arguments
+			for key, msg := range messages {
+				require.Equal(t, verbRegexp.FindAllString(key, -1), verbRegexp.FindAllString(msg, -1), "verbs of %q", key)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		acceptLanguage string
+		exp            language.Tag
+	}{
+		{"", language.English},
+		{"fr-FR,fr;q=0.9,en;q=0.8", language.French},
+		{"de-CH", language.German},
+		{"es-ES,de;q=0.5", language.German},
+		{"ja", language.English},
+		{"en-GB,fr;q=0.8", language.English},
+		{"!!invalid", language.English},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.acceptLanguage, func(t *testing.T) {
+			require.Equal(t, tc.exp, Match(tc.acceptLanguage))
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	tag, ok := Find("de")
+	require.True(t, ok)
+	require.Equal(t, language.German, tag)
+
+	_, ok = Find("es")
+	require.False(t, ok)
+	_, ok = Find("")
+	require.False(t, ok)
+}
+
+func TestT(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "Partition size", T(ctx, "Partition size"))
+	require.Equal(t, "12 bytes (12 B)", T(ctx, "%s bytes (%s)", "12", "12 B"))
+
+	ctx = WithLanguage(ctx, language.French)
+	require.Equal(t, language.French, LanguageOf(ctx))
+	require.Equal(t, "Taille de la partition", T(ctx, "Partition size"))
+	require.Equal(t, "12 octets (12 B)", T(ctx, "%s bytes (%s)", "12", "12 B"))
+
+	// Messages without translation are kept in English
+	require.Equal(t, "Foo bar", T(ctx, "Foo bar"))
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := NewError("the form is larger than the limit of %s", "1.0 MiB")
+	require.Equal(t, "the form is larger than the limit of 1.0 MiB", err.Error())
+	require.Equal(t, "le formulaire dépasse la limite de 1.0 MiB", ErrorMessage(NewPrinter(language.French), err))
+	require.Equal(t, "das Formular überschreitet die Grenze von 1.0 MiB", ErrorMessage(NewPrinter(language.German), err))
+
+	require.Equal(t, "foobar", ErrorMessage(NewPrinter(language.French), errors.New("foobar")))
+
+	// Errors in the arguments are translated and wrapped
+	inner := NewError("field empty")
+	err = NewError("field %q is invalid because of error: %s", "rows", inner)
+	require.Equal(t, `field "rows" is invalid because of error: field empty`, err.Error())
+	require.Equal(t, `le champ "rows" est invalide à cause de l'erreur : champ vide`, ErrorMessage(NewPrinter(language.French), err))
+	require.ErrorIs(t, err, inner)
+}
+
+type testMessage struct {
+	format string
+	args   []any
+}
+
+func (m testMessage) MessageFormat() (string, []any) {
+	return m.format, m.args
+}
+
+func (m testMessage) Error() string {
+	return fmt.Sprintf(m.format, m.args...)
+}
+
+func TestMessage(t *testing.T) {
+	msg := testMessage{format: "the form is larger than the limit of %s", args: []any{"1.0 MiB"}}
+
+	require.Equal(t, "the form is larger than the limit of 1.0 MiB", Message(context.Background(), msg))
+	require.Equal(t, "das Formular überschreitet die Grenze von 1.0 MiB", Message(WithLanguage(context.Background(), language.German), msg))
+	require.Equal(t, "le formulaire dépasse la limite de 1.0 MiB", ErrorMessage(NewPrinter(language.French), msg))
+}
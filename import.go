@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+type importCommandConfig struct {
+	root *rootCommandConfig
+
+	format      string
+	tablesPath  string
+	columnsPath string
+	typesPath   string
+}
+
+func newImportCommandConfig(root *rootCommandConfig) *ffcli.Command {
+	cfg := &importCommandConfig{
+		root:   root,
+		format: "describe",
+	}
+
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.StringVar(&cfg.format, "format", cfg.format, "Format of the input: describe, csv or json")
+	fs.StringVar(&cfg.tablesPath, "tables", "", "Path to the export of system_schema.tables (csv and json formats)")
+	fs.StringVar(&cfg.columnsPath, "columns", "", "Path to the export of system_schema.columns (csv and json formats)")
+	fs.StringVar(&cfg.typesPath, "types", "", "Path to the export of system_schema.types (csv and json formats)")
+
+	return &ffcli.Command{
+		Name:       "import",
+		ShortUsage: "import [flags] [describe output file]",
+		ShortHelp:  `import a schema from cqlsh DESCRIBE output or system_schema exports`,
+		LongHelp: strings.TrimSpace(`
+Import tables and user defined types from a running cluster and print them as CQL.
+
+With -format describe the input is the output of cqlsh's DESCRIBE KEYSPACE or DESCRIBE SCHEMA.
+
+With -format csv or -format json the input are exports of the system_schema tables, for example:
+
+  cqlsh -e "COPY system_schema.columns TO 'columns.csv' WITH HEADER = true"
+`),
+		FlagSet: fs,
+		Exec:    cfg.Exec,
+	}
+}
+
+func (c *importCommandConfig) Exec(ctx context.Context, args []string) error {
+	var (
+		tables []cql.Schema
+		types  []cql.UserType
+		err    error
+	)
+
+	switch c.format {
+	case "describe":
+		if len(args) < 1 {
+			return flag.ErrHelp
+		}
+
+		input, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("unable to read input file, err: %w", err)
+		}
+
+		tables, types, err = cql.ParseDescribe(string(input))
+		if err != nil {
+			return fmt.Errorf("unable to parse DESCRIBE output, err: %w", err)
+		}
+
+	case string(cassandra.SystemSchemaCSV), string(cassandra.SystemSchemaJSON):
+		export := cassandra.SystemSchemaExport{
+			Format: cassandra.SystemSchemaFormat(c.format),
+		}
+
+		readOptional := func(path string) (io.Reader, error) {
+			if path == "" {
+				return nil, nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read input file, err: %w", err)
+			}
+			return bytes.NewReader(data), nil
+		}
+
+		if export.Tables, err = readOptional(c.tablesPath); err != nil {
+			return err
+		}
+		if export.Columns, err = readOptional(c.columnsPath); err != nil {
+			return err
+		}
+		if export.Types, err = readOptional(c.typesPath); err != nil {
+			return err
+		}
+
+		tables, types, err = cassandra.ImportSystemSchema(export)
+		if err != nil {
+			return fmt.Errorf("unable to import system_schema, err: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("invalid format %q", c.format)
+	}
+
+	for _, typ := range types {
+		fmt.Println(cql.FormatUserType(typ))
+	}
+	fmt.Print(cql.FormatSchemas(tables))
+
+	return nil
+}
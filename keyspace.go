@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+	"rischmann.fr/cassandra-partition-calculator/lint"
+	"rischmann.fr/cassandra-partition-calculator/ui/fragments"
+)
+
+// tableRowsPrefix is the prefix of the form fields with the number of rows of a table of a keyspace.
+// The "rows" field is the number of rows of the tables without one.
+const tableRowsPrefix = "rows::"
+
+// tableInputKey identifies a table of a keyspace in the names of the form fields.
+// The size estimate of a column of the table is in the field "size::<key>.<column>".
+func tableInputKey(schema cql.Schema) string {
+	return strings.ToLower(schema.QualifiedName())
+}
+
+// tableRowsFromForm returns the valid numbers of rows of the tables of the evaluate form, or nil if there are none.
+func tableRowsFromForm(form url.Values) map[string]int64 {
+	var res map[string]int64
+
+	for name, value := range form {
+		if !strings.HasPrefix(name, tableRowsPrefix) || len(value) == 0 {
+			continue
+		}
+
+		rows, err := strconv.ParseInt(value[0], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if res == nil {
+			res = make(map[string]int64)
+		}
+		res[name[len(tableRowsPrefix):]] = rows
+	}
+
+	return res
+}
+
+// isKeyspaceForm returns true if the schema of the evaluate form has several tables.
+func (c *serveCommandConfig) isKeyspaceForm(form url.Values) bool {
+	tables, _, err := c.schemaCache.parseDescribe(form.Get("schema"))
+	return err == nil && len(tables) > 1
+}
+
+// parseKeyspaceForm parses the evaluate form of a schema with several tables, it returns the evaluation of every table.
+func (c *serveCommandConfig) parseKeyspaceForm(form url.Values) ([]evaluationSchema, error) {
+	// The default number of rows, the units and the schema are validated like with a single table
+	defaults, err := c.parseEvaluateForm(form)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, _, err := c.schemaCache.parseDescribe(form.Get("schema"))
+	if err != nil {
+		return nil, &validationError{
+			field: "schema",
+			err:   err,
+		}
+	}
+
+	res := make([]evaluationSchema, 0, len(tables))
+	seen := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		if err := cql.Validate(table); err != nil {
+			return nil, err
+		}
+
+		// The fields of two tables with the same key would overwrite each other
+		key := tableInputKey(table)
+		if seen[key] {
+			return nil, &validationError{
+				field: "schema",
+				err:   i18n.NewError("table %q is defined more than once", table.QualifiedName()),
+			}
+		}
+		seen[key] = true
+
+		evaluation := evaluationSchema{
+			rows:   defaults.rows,
+			schema: table,
+			units:  defaults.units,
+		}
+
+		if rowsStr := form.Get(tableRowsPrefix + key); rowsStr != "" {
+			evaluation.rows, err = strconv.ParseInt(rowsStr, 10, 64)
+			if err != nil {
+				return nil, &validationError{
+					field: tableRowsPrefix + key,
+					err:   err,
+				}
+			}
+			if evaluation.rows < 0 {
+				return nil, &validationError{
+					field: tableRowsPrefix + key,
+					err:   errNegativeValue,
+				}
+			}
+		}
+
+		// Like with a single table the estimates of unknown and fixed size columns are ignored
+		prefix := "size::" + key + "."
+		for name, value := range form {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+
+			sizeEstimate, err := strconv.Atoi(value[0])
+			if err != nil {
+				return nil, &validationError{
+					field: name,
+					err:   err,
+				}
+			}
+
+			evaluation.schema, err = withColumnSizeEstimate(evaluation.schema, name, name[len(prefix):], sizeEstimate)
+			if err != nil && !isStaleSizeEstimate(err) {
+				return nil, err
+			}
+		}
+
+		res = append(res, evaluation)
+	}
+
+	return res, nil
+}
+
+// evaluateKeyspace estimates the partition size of every table of a schema with several tables.
+// Errors are returned in the result data to be displayed next to the form.
+func (c *serveCommandConfig) evaluateKeyspace(ctx context.Context, form url.Values) fragments.ResultsData {
+	evaluations, err := c.parseKeyspaceForm(form)
+	if err != nil {
+		c.logger(ctx).Debug("unable to parse keyspace evaluate request", zap.Error(err))
+
+		return fragments.ResultsData{
+			ErrorMessages: errorMessages(ctx, err),
+			SchemaErrors:  schemaErrors(ctx, err),
+			SizeEstimates: formSizeEstimates(form),
+			TableRows:     formTableRows(form),
+		}
+	}
+
+	formatter := newFormatter(ctx, evaluations[0].units)
+
+	var (
+		keyspace    fragments.KeyspaceResults
+		totalValues int64
+		totalBytes  int64
+		tables      = make([]chartTable, 0, len(evaluations))
+	)
+	for _, evaluation := range evaluations {
+		estimation, err := c.metrics.estimate(evaluation.schema, evaluation.rows)
+		if err != nil {
+			c.logger(ctx).Error("unable to estimate", zap.String("table", evaluation.schema.QualifiedName()), zap.Error(err))
+
+			return fragments.ResultsData{
+				ErrorMessages: errorMessages(ctx, err),
+			}
+		}
+
+		keyspace.Tables = append(keyspace.Tables, fragments.TableResults{
+			Key:    tableInputKey(evaluation.schema),
+			Name:   evaluation.schema.QualifiedName(),
+			Rows:   strconv.FormatInt(evaluation.rows, 10),
+			Schema: evaluation.schema,
+			Estimation: fragments.Estimation{
+				Values: formatter.Number(int64(estimation.Values)),
+				Bytes:  formatter.DetailedBytes(int64(estimation.Bytes)),
+			},
+			Findings: lint.Run(c.lintConfig, evaluation.schema),
+		})
+
+		totalValues += int64(estimation.Values)
+		totalBytes += int64(estimation.Bytes)
+		tables = append(tables, chartTable{schema: evaluation.schema, rows: evaluation.rows})
+	}
+
+	keyspace.Totals = fragments.Estimation{
+		Values: formatter.Number(totalValues),
+		Bytes:  formatter.DetailedBytes(totalBytes),
+	}
+
+	res := fragments.ResultsData{
+		Keyspace: &keyspace,
+	}
+	c.addResultLinks(ctx, &res, formatter, form, tables)
+
+	return res
+}
+
+// formTableRows returns the numbers of rows of the tables of the form sorted by table.
+func formTableRows(form url.Values) []fragments.TableRows {
+	tableRows := tableRowsFromForm(form)
+
+	res := make([]fragments.TableRows, 0, len(tableRows))
+	for table, rows := range tableRows {
+		res = append(res, fragments.TableRows{Table: table, Rows: rows})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Table < res[j].Table })
+
+	return res
+}
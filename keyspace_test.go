@@ -0,0 +1,198 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+const testKeyspaceSchema = `
+CREATE TABLE ks.users(user_id uuid PRIMARY KEY, name text);
+CREATE TABLE ks.events(user_id uuid, event_id timeuuid, data blob, PRIMARY KEY (user_id, event_id));
+`
+
+func columnSize(t testing.TB, schema cql.Schema, name string) int {
+	for _, column := range schema.Columns {
+		if column.Name == name {
+			return column.Size()
+		}
+	}
+	require.Failf(t, "unknown column", "column %q not found", name)
+	return 0
+}
+
+func TestParseKeyspaceForm(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	form := url.Values{
+		"schema":               {testKeyspaceSchema},
+		"rows":                 {"100"},
+		"rows::ks.events":      {"1000"},
+		"size::ks.users.name":  {"20"},
+		"size::ks.events.data": {"50"},
+		// Left over from a previous version of the schema
+		"size::ks.events.payload": {"10"},
+	}
+	require.True(t, c.isKeyspaceForm(form))
+
+	evaluations, err := c.parseKeyspaceForm(form)
+	require.NoError(t, err)
+	require.Len(t, evaluations, 2)
+
+	require.Equal(t, "ks.users", evaluations[0].schema.QualifiedName())
+	require.Equal(t, int64(100), evaluations[0].rows)
+	require.Equal(t, 20, columnSize(t, evaluations[0].schema, "name"))
+
+	require.Equal(t, "ks.events", evaluations[1].schema.QualifiedName())
+	require.Equal(t, int64(1000), evaluations[1].rows)
+	require.Equal(t, 50, columnSize(t, evaluations[1].schema, "data"))
+
+	// Errors
+
+	form.Set("rows::ks.events", "foo")
+	_, err = c.parseKeyspaceForm(form)
+	require.ErrorContains(t, err, `field "rows::ks.events" is invalid`)
+
+	form.Set("rows::ks.events", "-5")
+	_, err = c.parseKeyspaceForm(form)
+	require.ErrorIs(t, err, errNegativeValue)
+
+	form.Set("size::ks.users.name", "foo")
+	form.Set("rows::ks.events", "1000")
+	_, err = c.parseKeyspaceForm(form)
+	require.ErrorContains(t, err, `field "size::ks.users.name" is invalid`)
+
+	// The estimates of columns whose type changed to a fixed size one are ignored
+	form.Set("size::ks.users.name", "20")
+	form.Set("size::ks.users.user_id", "10")
+	_, err = c.parseKeyspaceForm(form)
+	require.NoError(t, err)
+
+	// The names of the fields don't distinguish tables differing only by case
+	form.Set("schema", testKeyspaceSchema+`CREATE TABLE ks.Users(user_id uuid PRIMARY KEY);`)
+	_, err = c.parseKeyspaceForm(form)
+	require.ErrorContains(t, err, `table "ks.Users" is defined more than once`)
+
+	require.False(t, c.isKeyspaceForm(url.Values{"schema": {"CREATE TABLE users(user_id uuid PRIMARY KEY);"}}))
+}
+
+func TestEvaluateHandlerKeyspace(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	form := url.Values{
+		"schema":               {testKeyspaceSchema},
+		"rows":                 {"100"},
+		"rows::ks.events":      {"1000"},
+		"size::ks.events.data": {"50"},
+	}
+
+	rec := doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+
+	// One card per table with its own inputs
+	require.Contains(t, body, `<div id="columns" class="keyspace">`)
+	require.Equal(t, 2, strings.Count(body, `<section class="table-card">`))
+	require.Contains(t, body, "<h3>ks.users</h3>")
+	require.Contains(t, body, "<h3>ks.events</h3>")
+	require.Contains(t, body, `id="rows-ks-users" name="rows::ks.users" value="100"`)
+	require.Contains(t, body, `id="rows-ks-events" name="rows::ks.events" value="1000"`)
+	require.Contains(t, body, `id="size-ks-events-data" name="size::ks.events.data" value="50"`)
+	require.Contains(t, body, `name="size::ks.users.name"`)
+
+	// Keyspace totals
+	require.Contains(t, body, "<pre>2</pre>")
+	require.Contains(t, body, "Partition size of every table")
+	require.Contains(t, body, `id="permalink"`)
+
+	// The charts mark the number of rows of every table
+	require.Contains(t, body, "100 rows")
+	require.Contains(t, body, "1k rows")
+
+	// The inputs of the tables are kept when there are errors
+	form.Set("rows", "")
+
+	rec = doEvaluate(t, c, form, true)
+	body = rec.Body.String()
+	require.Contains(t, body, `class="error-message"`)
+	require.Contains(t, body, `<input type="hidden" name="rows::ks.events" value="1000">`)
+	require.Contains(t, body, `<input type="hidden" name="size::ks.events.data" value="50">`)
+
+	// A stale estimate of a column which became fixed size doesn't fail the evaluation
+	form.Set("rows", "100")
+	form.Set("schema", strings.Replace(testKeyspaceSchema, "name text", "name int", 1))
+	form.Set("size::ks.users.name", "20")
+
+	rec = doEvaluate(t, c, form, true)
+	body = rec.Body.String()
+	require.NotContains(t, body, `class="error-message"`)
+	require.NotContains(t, body, `name="size::ks.users.name"`)
+}
+
+func TestKeyspacePermalinkAndExport(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+	handler := c.routes()
+
+	form := url.Values{
+		"schema":               {testKeyspaceSchema},
+		"rows":                 {"100"},
+		"rows::ks.events":      {"1000"},
+		"size::ks.events.data": {"50"},
+	}
+
+	state := newPermalinkState(form)
+	require.Equal(t, map[string]int64{"ks.events": 1000}, state.TableRows)
+	require.Equal(t, form, state.Form())
+
+	encoded, err := state.Encode()
+	require.NoError(t, err)
+
+	// The permalink restores every table
+	req := httptest.NewRequest(http.MethodGet, "/?state="+encoded, nil)
+	rec := httptest.NewRecorder()
+	c.indexHandler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `name="rows::ks.events" value="1000"`)
+
+	// The export has every table
+	rec = doRequest(t, handler, http.MethodGet, "/export?format=markdown&state="+encoded, "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `attachment; filename=ks.md`, rec.Header().Get("Content-Disposition"))
+
+	body := rec.Body.String()
+	require.True(t, strings.HasPrefix(body, "# Partition sizes of 2 tables\n"))
+	require.Contains(t, body, "## ks.users\n")
+	require.Contains(t, body, "## ks.events\n")
+	require.Contains(t, body, "| Rows | 1,000 |\n")
+}
+
+func TestSaveKeyspaceScenario(t *testing.T) {
+	c := newTestScenariosServeCommandConfig(t)
+
+	form := url.Values{
+		"scenario_name":        {"keyspace"},
+		"schema":               {testKeyspaceSchema},
+		"rows":                 {"100"},
+		"rows::ks.events":      {"1000"},
+		"size::ks.events.data": {"50"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/scenarios", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	saved, err := c.saveScenario(req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"ks.events": 1000}, saved.TableRows)
+
+	restored := scenarioForm(saved)
+	require.Equal(t, "1000", restored.Get("rows::ks.events"))
+	require.Equal(t, "50", restored.Get("size::ks.events.data"))
+}
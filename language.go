@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vrischmann/hutil/v3"
+	"golang.org/x/text/language"
+
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+	"rischmann.fr/cassandra-partition-calculator/ui"
+)
+
+const (
+	// languageParam is the query parameter of the language switcher
+	languageParam = "lang"
+	// languageCookie remembers the language chosen with the language switcher
+	languageCookie = "lang"
+)
+
+// requestLanguage returns the language of the request, in order of priority:
+//   - the language chosen with the language switcher, the lang query parameter
+//   - the language chosen previously, remembered in the lang cookie
+//   - the best language matching the Accept-Language header
+//
+// chosen is true if the language comes from the query parameter.
+func requestLanguage(req *http.Request) (tag language.Tag, chosen bool) {
+	if tag, ok := i18n.Find(req.URL.Query().Get(languageParam)); ok {
+		return tag, true
+	}
+	if cookie, err := req.Cookie(languageCookie); err == nil {
+		if tag, ok := i18n.Find(cookie.Value); ok {
+			return tag, false
+		}
+	}
+	return i18n.Match(req.Header.Get("Accept-Language")), false
+}
+
+// newLanguageMiddleware sets the language the responses are translated in.
+func (c *serveCommandConfig) newLanguageMiddleware() hutil.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			tag, chosen := requestLanguage(req)
+			if chosen {
+				http.SetCookie(w, &http.Cookie{
+					Name:     languageCookie,
+					Value:    tag.String(),
+					Path:     c.cookiePath(req),
+					MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+			w.Header().Add("Vary", "Accept-Language, Cookie")
+
+			next.ServeHTTP(w, req.WithContext(i18n.WithLanguage(req.Context(), tag)))
+		})
+	}
+}
+
+// cookiePath is the path of the cookies, the base path of the application.
+func (c *serveCommandConfig) cookiePath(req *http.Request) string {
+	u, err := url.Parse(c.baseURLOf(req.Context()))
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// languageLinks returns the links showing the current page in every language.
+// The links of the pages which can't be requested again, like the results of a submitted form, go to the root page.
+func (c *serveCommandConfig) languageLinks(req *http.Request) []ui.LanguageLink {
+	current := i18n.LanguageOf(req.Context())
+
+	page, query := "/", url.Values{}
+	if req.Method == http.MethodGet {
+		page, query = req.URL.Path, req.URL.Query()
+	}
+	base := c.baseURLOf(req.Context()) + page
+
+	res := make([]ui.LanguageLink, 0, len(i18n.Languages))
+	for _, lang := range i18n.Languages {
+		query.Set(languageParam, lang.Tag.String())
+
+		res = append(res, ui.LanguageLink{
+			Lang:    lang.Tag.String(),
+			Name:    lang.Name,
+			URL:     base + "?" + query.Encode(),
+			Current: lang.Tag == current,
+		})
+	}
+
+	return res
+}
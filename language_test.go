@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguage(t *testing.T) {
+	c := newTestScenariosServeCommandConfig(t)
+
+	handler, err := c.handler()
+	require.NoError(t, err)
+
+	get := func(t *testing.T, target string, headers ...string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for i := 0; i < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("default", func(t *testing.T) {
+		rec := get(t, "/")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), `<html lang="en">`)
+		require.Contains(t, rec.Body.String(), "Estimated number of rows")
+		require.Contains(t, rec.Body.String(), `<strong lang="en">English</strong>`)
+		require.Contains(t, rec.Header().Values("Vary"), "Accept-Language, Cookie")
+	})
+
+	t.Run("accept-language", func(t *testing.T) {
+		rec := get(t, "/", "Accept-Language", "fr-FR,fr;q=0.9,en;q=0.8")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), `<html lang="fr">`)
+		require.Contains(t, rec.Body.String(), "<title>Calculateur de partitions Cassandra</title>")
+		require.Contains(t, rec.Body.String(), "Nombre de lignes estimé")
+		require.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("switcher", func(t *testing.T) {
+		rec := get(t, "/?example=messaging&lang=de", "Accept-Language", "fr")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), `<html lang="de">`)
+		require.Contains(t, rec.Body.String(), "Geschätzte Anzahl der Zeilen")
+
+		// The links keep the current page
+		require.Contains(t, rec.Body.String(), `<a href="/?example=messaging&amp;lang=fr" hreflang="fr" lang="fr">Français</a>`)
+		require.Contains(t, rec.Body.String(), `<strong lang="de">Deutsch</strong>`)
+
+		// The choice is remembered and overrides the header
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, "lang", cookies[0].Name)
+		require.Equal(t, "de", cookies[0].Value)
+		require.Equal(t, "/", cookies[0].Path)
+
+		rec = get(t, "/compare", "Accept-Language", "fr", "Cookie", "lang=de")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), `<html lang="de">`)
+		require.Contains(t, rec.Body.String(), ">Vergleichen</a>")
+
+		// Unknown languages are ignored
+		rec = get(t, "/?lang=es", "Accept-Language", "fr")
+		require.Contains(t, rec.Body.String(), `<html lang="fr">`)
+		require.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("results", func(t *testing.T) {
+		form := url.Values{
+			"schema": {"CREATE TABLE events(user_id uuid PRIMARY KEY, name text);"},
+			"rows":   {"1000"},
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		req.Header.Set("Accept-Language", "fr")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), "Taille de la partition")
+		require.Contains(t, rec.Body.String(), " octets (")
+		require.Contains(t, rec.Body.String(), "Rapport HTML")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		form := url.Values{
+			"schema": {""},
+			"rows":   {"1000"},
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		req.Header.Set("Cookie", "lang=de")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Contains(t, rec.Body.String(), `Feld &#34;schema&#34; ist ungültig wegen des Fehlers: Feld leer`)
+		require.Contains(t, rec.Body.String(), "Anfrage-ID:")
+
+		rec = get(t, "/?example=foo", "Accept-Language", "fr")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.Contains(t, rec.Body.String(), "exemple &#34;foo&#34; inconnu")
+	})
+
+	t.Run("schema-errors", func(t *testing.T) {
+		evaluate := func(t *testing.T, schema string) string {
+			t.Helper()
+
+			form := url.Values{
+				"schema": {schema},
+				"rows":   {"1000"},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("HX-Request", "true")
+			req.Header.Set("Accept-Language", "fr")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			return rec.Body.String()
+		}
+
+		// Violations
+		body := evaluate(t, "CREATE TABLE events(id uuid PRIMARY KEY, id text);")
+		require.Contains(t, body, `1:42: la colonne &#34;id&#34; est définie plusieurs fois`)
+		require.Contains(t, body, `data-message="la colonne &#34;id&#34; est définie plusieurs fois"`)
+
+		// Syntax errors
+		body = evaluate(t, "CREATE TABLE events(id uuid PRIMARY KEY, name text")
+		require.Contains(t, body, `le champ &#34;schema&#34; est invalide à cause de l&#39;erreur : 1:51: plus d&#39;espace à trouver, erreur : fin du fichier`)
+		require.Contains(t, body, `data-message="plus d&#39;espace à trouver, erreur : fin du fichier"`)
+	})
+
+	t.Run("compare", func(t *testing.T) {
+		query := url.Values{
+			"scenario": {"foo"},
+			"schema":   {"CREATE TABLE flags(enabled boolean, name text, PRIMARY KEY (enabled, name));"},
+			"rows":     {"10"},
+		}
+
+		rec := get(t, "/compare?"+query.Encode(), "Accept-Language", "fr")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), "Comparez la taille des partitions de plusieurs conceptions")
+		require.Contains(t, rec.Body.String(), "<th>Taille de la partition</th>")
+		require.Contains(t, rec.Body.String(), "<th>Avertissements</th>")
+		require.Contains(t, rec.Body.String(), "Scénario foo")
+		require.Contains(t, rec.Body.String(), "identifiant de scénario &#34;foo&#34; invalide")
+		require.Contains(t, rec.Body.String(), "Conception 2")
+		require.Contains(t, rec.Body.String(), "la clé de partition est une seule colonne boolean")
+	})
+
+	t.Run("scenarios", func(t *testing.T) {
+		rec := get(t, "/scenarios", "Accept-Language", "de")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Body.String(), "Gespeicherte Szenarien")
+		require.Contains(t, rec.Body.String(), "Noch kein Szenario gespeichert.")
+	})
+}
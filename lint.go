@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+	"rischmann.fr/cassandra-partition-calculator/lint"
+)
+
+// registerLintFlags registers the flags configuring the lint rules on fs.
+func registerLintFlags(fs *flag.FlagSet, cfg *lint.Config) {
+	fs.Func("lint-disable", "Comma separated list of lint rules to disable", func(data string) error {
+		for _, name := range strings.Split(data, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := lint.FindRule(name); !ok {
+				return fmt.Errorf("unknown lint rule %q", name)
+			}
+			cfg.Disabled[name] = true
+		}
+		return nil
+	})
+	fs.IntVar(&cfg.MaxColumns, "lint-max-columns", cfg.MaxColumns, "Maximum number of columns in a table")
+	fs.Func("lint-write-heavy-tables", "Comma separated list of heavily written tables (default all tables)", func(data string) error {
+		for _, name := range strings.Split(data, ",") {
+			cfg.WriteHeavyTables = append(cfg.WriteHeavyTables, strings.TrimSpace(name))
+		}
+		return nil
+	})
+}
+
+type lintCommandConfig struct {
+	root *rootCommandConfig
+
+	lintConfig lint.Config
+	listRules  bool
+}
+
+func newLintCommandConfig(root *rootCommandConfig) *ffcli.Command {
+	cfg := &lintCommandConfig{
+		root:       root,
+		lintConfig: lint.DefaultConfig(),
+	}
+
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	registerLintFlags(fs, &cfg.lintConfig)
+	fs.BoolVar(&cfg.listRules, "list-rules", false, "List the available rules and exit")
+
+	return &ffcli.Command{
+		Name:       "lint",
+		ShortUsage: "lint [flags] <schema file>",
+		ShortHelp:  `lint the data model of a CQL schema`,
+		FlagSet:    fs,
+		Exec:       cfg.Exec,
+	}
+}
+
+func (c *lintCommandConfig) Exec(ctx context.Context, args []string) error {
+	if c.listRules {
+		for _, rule := range lint.Rules {
+			fmt.Printf("%-36s %-8s %s\n", rule.Name, rule.Severity, rule.Description)
+		}
+		return nil
+	}
+
+	if len(args) < 1 {
+		return flag.ErrHelp
+	}
+
+	input, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("unable to read input file, err: %w", err)
+	}
+
+	schema, err := cql.ParseSchema(string(input))
+	if err != nil {
+		return fmt.Errorf("unable to parse schema, err: %w", err)
+	}
+
+	findings := lint.Run(c.lintConfig, schema)
+
+	errorCount := 0
+	for _, finding := range findings {
+		fmt.Printf("%s: %s\n", args[0], finding)
+
+		if finding.Severity == lint.SeverityError {
+			errorCount++
+		}
+	}
+
+	if err := c.validate(schema); err != nil {
+		return fmt.Errorf("invalid schema, err: %w", err)
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("found %d lint errors", errorCount)
+	}
+
+	return nil
+}
+
+// validate validates the schema like cql.Validate, without the violations already reported by an enabled rule.
+func (c *lintCommandConfig) validate(schema cql.Schema) error {
+	err := cql.Validate(schema)
+
+	var violations cql.Violations
+	if !errors.As(err, &violations) {
+		return err
+	}
+
+	// The rules check the same columns as the validation
+	reported := make(map[cql.Position]bool)
+	if !c.lintConfig.Disabled["duplicate-column"] {
+		for _, column := range cql.DuplicateColumns(schema) {
+			reported[column.Pos] = true
+		}
+	}
+	if !c.lintConfig.Disabled["static-without-clustering-key"] {
+		for _, column := range cql.MisplacedStaticColumns(schema) {
+			reported[column.Pos] = true
+		}
+	}
+
+	var res cql.Violations
+	for _, violation := range violations {
+		if !reported[violation.Pos] {
+			res = append(res, violation)
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+
+	return res
+}
@@ -0,0 +1,247 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Finding is a single problem reported by a rule.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Table    string
+	Column   string
+	Message  string
+
+	// format and args are the parts of Message, used to translate it
+	format string
+	args   []any
+}
+
+func (f Finding) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(f.Table)
+	if f.Column != "" {
+		sb.WriteString(".")
+		sb.WriteString(f.Column)
+	}
+	fmt.Fprintf(&sb, ": %s [%s] %s", f.Severity, f.Rule, f.Message)
+
+	return sb.String()
+}
+
+// MessageFormat returns the format and the arguments of Message, for its translation.
+func (f Finding) MessageFormat() (string, []any) {
+	return f.format, f.args
+}
+
+// Config controls which rules run and how they behave.
+type Config struct {
+	// Disabled contains the names of the rules that must not run.
+	Disabled map[string]bool
+
+	// MaxColumns is the number of columns above which a table is reported.
+	MaxColumns int
+	// LowCardinalityTypes are the types considered too small to be the only partition key column.
+	LowCardinalityTypes []string
+	// WriteHeavyTables are the tables considered heavily written.
+	// If empty every table is considered heavily written.
+	WriteHeavyTables []string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Disabled:            make(map[string]bool),
+		MaxColumns:          50,
+		LowCardinalityTypes: []string{"boolean", "tinyint", "smallint"},
+	}
+}
+
+func (c Config) isWriteHeavy(table string) bool {
+	if len(c.WriteHeavyTables) == 0 {
+		return true
+	}
+	for _, name := range c.WriteHeavyTables {
+		if name == table {
+			return true
+		}
+	}
+	return false
+}
+
+type Rule struct {
+	Name        string
+	Description string
+	Severity    Severity
+
+	check func(cfg Config, schema cql.Schema, report func(column, format string, args ...any))
+}
+
+// Rules is the list of all known rules, in the order they run.
+// The error rules find what Cassandra would reject, with the same checks as cql.Validate.
+var Rules = []Rule{
+	{
+		Name:        "low-cardinality-partition-key",
+		Description: "the partition key is a single column with a low cardinality type",
+		Severity:    SeverityWarning,
+		check:       checkLowCardinalityPartitionKey,
+	},
+	{
+		Name:        "time-series-without-clustering-key",
+		Description: "the table stores time based data but has no clustering key",
+		Severity:    SeverityWarning,
+		check:       checkTimeSeriesWithoutClusteringKey,
+	},
+	{
+		Name:        "unfrozen-collection",
+		Description: "a heavily written table has a non frozen collection column",
+		Severity:    SeverityWarning,
+		check:       checkUnfrozenCollection,
+	},
+	{
+		Name:        "too-many-columns",
+		Description: "the table has more columns than the configured maximum",
+		Severity:    SeverityWarning,
+		check:       checkTooManyColumns,
+	},
+	{
+		Name:        "blob-in-primary-key",
+		Description: "a blob column is part of the primary key",
+		Severity:    SeverityWarning,
+		check:       checkBlobInPrimaryKey,
+	},
+	{
+		Name:        "static-without-clustering-key",
+		Description: "a static column is defined on a table without clustering columns",
+		Severity:    SeverityError,
+		check:       checkStaticWithoutClusteringKey,
+	},
+	{
+		Name:        "duplicate-column",
+		Description: "a column is defined more than once",
+		Severity:    SeverityError,
+		check:       checkDuplicateColumn,
+	},
+}
+
+// FindRule returns the rule with the given name.
+func FindRule(name string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Run runs all enabled rules on the schema and returns the findings.
+func Run(cfg Config, schema cql.Schema) []Finding {
+	var res []Finding
+
+	for _, rule := range Rules {
+		if cfg.Disabled[rule.Name] {
+			continue
+		}
+
+		rule.check(cfg, schema, func(column, format string, args ...any) {
+			res = append(res, Finding{
+				Rule:     rule.Name,
+				Severity: rule.Severity,
+				Table:    schema.TableName,
+				Column:   column,
+				Message:  fmt.Sprintf(format, args...),
+				format:   format,
+				args:     args,
+			})
+		})
+	}
+
+	return res
+}
+
+func checkLowCardinalityPartitionKey(cfg Config, schema cql.Schema, report func(column, format string, args ...any)) {
+	columns := schema.PrimaryKey.PartitionKey.Columns
+	if len(columns) != 1 {
+		return
+	}
+
+	column := columns[0]
+	for _, typ := range cfg.LowCardinalityTypes {
+		if column.Type.Name == typ {
+			report(column.Name, "partition key is a single %s column, all rows will end up in very few partitions", typ)
+			return
+		}
+	}
+}
+
+func isTimeType(typ cql.DataType) bool {
+	switch typ.Name {
+	case "timestamp", "timeuuid", "date", "time":
+		return true
+	default:
+		return false
+	}
+}
+
+func checkTimeSeriesWithoutClusteringKey(_ Config, schema cql.Schema, report func(column, format string, args ...any)) {
+	if len(schema.PrimaryKey.ClusteringKey.Columns) > 0 {
+		return
+	}
+
+	for _, column := range schema.Columns {
+		if isTimeType(column.Type) {
+			report(column.Name, "table has a %s column but no clustering key, each new event overwrites the previous one", column.Type.Name)
+			return
+		}
+	}
+}
+
+func checkUnfrozenCollection(cfg Config, schema cql.Schema, report func(column, format string, args ...any)) {
+	if !cfg.isWriteHeavy(schema.TableName) {
+		return
+	}
+
+	for _, column := range schema.Columns {
+		if column.Type.IsCollection() && !column.Type.IsFrozen() {
+			report(column.Name, "non frozen collections create tombstones when overwritten, consider using frozen<>")
+		}
+	}
+}
+
+func checkTooManyColumns(cfg Config, schema cql.Schema, report func(column, format string, args ...any)) {
+	if cfg.MaxColumns <= 0 || len(schema.Columns) <= cfg.MaxColumns {
+		return
+	}
+
+	report("", "table has %d columns, the maximum is %d", len(schema.Columns), cfg.MaxColumns)
+}
+
+func checkBlobInPrimaryKey(_ Config, schema cql.Schema, report func(column, format string, args ...any)) {
+	for _, column := range schema.PrimaryKey.Columns() {
+		if column.Type.Name == "blob" {
+			report(column.Name, "blob columns in the primary key are hard to query and have an unbounded size")
+		}
+	}
+}
+
+func checkStaticWithoutClusteringKey(_ Config, schema cql.Schema, report func(column, format string, args ...any)) {
+	for _, column := range cql.MisplacedStaticColumns(schema) {
+		report(column.Name, "static columns are only allowed on tables with clustering columns")
+	}
+}
+
+func checkDuplicateColumn(_ Config, schema cql.Schema, report func(column, format string, args ...any)) {
+	for _, column := range cql.DuplicateColumns(schema) {
+		report(column.Name, "column is defined more than once")
+	}
+}
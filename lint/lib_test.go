@@ -0,0 +1,145 @@
+package lint
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+func ruleNames(findings []Finding) []string {
+	res := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		res = append(res, finding.Rule)
+	}
+	return res
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		input  string
+		modify func(cfg *Config)
+		exp    []string
+	}{
+		{
+			input: `CREATE TABLE events(
+				tenant_key bigint,
+				event_id timeuuid,
+				event_data blob,
+				PRIMARY KEY (tenant_key, event_id)
+			);`,
+			exp: []string{},
+		},
+		{
+			input: `CREATE TABLE flags(
+				enabled boolean,
+				name text,
+				PRIMARY KEY (enabled, name)
+			);`,
+			exp: []string{"low-cardinality-partition-key"},
+		},
+		{
+			input: `CREATE TABLE readings(
+				sensor_id uuid PRIMARY KEY,
+				read_at timestamp,
+				value double
+			);`,
+			exp: []string{"time-series-without-clustering-key"},
+		},
+		{
+			input: `CREATE TABLE users(
+				user_id uuid PRIMARY KEY,
+				emails set<text>,
+				tags frozen<list<text>>
+			);`,
+			exp: []string{"unfrozen-collection"},
+		},
+		{
+			input: `CREATE TABLE users(
+				user_id uuid PRIMARY KEY,
+				emails set<text>
+			);`,
+			modify: func(cfg *Config) {
+				cfg.WriteHeavyTables = []string{"events"}
+			},
+			exp: []string{},
+		},
+		{
+			input: `CREATE TABLE users(
+				user_id uuid PRIMARY KEY,
+				name text,
+				email text
+			);`,
+			modify: func(cfg *Config) {
+				cfg.MaxColumns = 2
+			},
+			exp: []string{"too-many-columns"},
+		},
+		{
+			input: `CREATE TABLE files(
+				hash blob PRIMARY KEY,
+				name text
+			);`,
+			exp: []string{"blob-in-primary-key"},
+		},
+		{
+			input: `CREATE TABLE files(
+				hash blob PRIMARY KEY,
+				name text
+			);`,
+			modify: func(cfg *Config) {
+				cfg.Disabled["blob-in-primary-key"] = true
+			},
+			exp: []string{},
+		},
+		{
+			input: `CREATE TABLE users(
+				user_id uuid PRIMARY KEY,
+				name text STATIC
+			);`,
+			exp: []string{"static-without-clustering-key"},
+		},
+		{
+			input: `CREATE TABLE users(
+				user_id uuid PRIMARY KEY,
+				name text,
+				name text
+			);`,
+			exp: []string{"duplicate-column"},
+		},
+		{
+			input: `CREATE TABLE users(
+				user_id uuid PRIMARY KEY,
+				name text,
+				name text
+			);`,
+			modify: func(cfg *Config) {
+				cfg.Disabled["duplicate-column"] = true
+			},
+			exp: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			schema, err := cql.ParseSchema(tc.input)
+			require.NoError(t, err)
+
+			cfg := DefaultConfig()
+			if tc.modify != nil {
+				tc.modify(&cfg)
+			}
+
+			findings := Run(cfg, schema)
+			require.Equal(t, tc.exp, ruleNames(findings))
+
+			// The message is kept with its format for its translation
+			for _, finding := range findings {
+				format, args := finding.MessageFormat()
+				require.Equal(t, finding.Message, fmt.Sprintf(format, args...))
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"rischmann.fr/cassandra-partition-calculator/lint"
+)
+
+func TestLintCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		disabled []string
+		err      string
+	}{
+		{
+			name:  "valid",
+			input: "CREATE TABLE users(user_id uuid PRIMARY KEY, name text);",
+		},
+		{
+			name:  "duplicate-column",
+			input: "CREATE TABLE users(user_id uuid PRIMARY KEY, name text, name text);",
+			err:   "found 1 lint errors",
+		},
+		{
+			name:  "static-without-clustering-key",
+			input: "CREATE TABLE users(user_id uuid PRIMARY KEY, name text STATIC);",
+			err:   "found 1 lint errors",
+		},
+		{
+			name:     "disabled-rule",
+			input:    "CREATE TABLE users(user_id uuid PRIMARY KEY, name text, name text);",
+			disabled: []string{"duplicate-column"},
+			err:      `invalid schema, err: 1:57: column "name" is defined more than once`,
+		},
+		{
+			name:  "not-a-rule",
+			input: "CREATE TABLE users(user_id uuid, name text);",
+			err:   `invalid schema, err: 1:1: table "users" has no PRIMARY KEY`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schema.cql")
+			require.NoError(t, os.WriteFile(path, []byte(tc.input), 0o644))
+
+			c := &lintCommandConfig{lintConfig: lint.DefaultConfig()}
+			for _, name := range tc.disabled {
+				c.lintConfig.Disabled[name] = true
+			}
+
+			err := c.Exec(context.Background(), []string{path})
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
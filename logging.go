@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vrischmann/hutil/v3"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type logFormat string
+
+const (
+	logFormatConsole logFormat = "console"
+	logFormatJSON    logFormat = "json"
+)
+
+func parseLogFormat(s string) (logFormat, error) {
+	switch format := logFormat(s); format {
+	case logFormatConsole, logFormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid log format %q, expected console or json", s)
+	}
+}
+
+// newLogger creates a logger writing the logs of at least level to stderr.
+//
+// The console format is meant to be read by humans while developing,
+// the JSON format is meant to be ingested by a log collector in production.
+func newLogger(level zapcore.Level, format logFormat) (*zap.Logger, error) {
+	var config zap.Config
+	switch format {
+	case logFormatJSON:
+		config = zap.NewProductionConfig()
+	default:
+		config = zap.NewDevelopmentConfig()
+	}
+	config.Level = zap.NewAtomicLevelAt(level)
+
+	return config.Build()
+}
+
+//
+// Request IDs
+//
+
+// requestIDHeader is the header containing the ID of a request.
+// The ID given by a proxy in front of the server is kept, otherwise a new one is generated.
+const requestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength limits the size of the request IDs given by the clients.
+const maxRequestIDLength = 128
+
+type contextKey int
+
+const (
+	requestIDContextKey contextKey = iota
+	loggerContextKey
+)
+
+func newRequestID() string {
+	var data [16]byte
+	if _, err := rand.Read(data[:]); err != nil {
+		panic(fmt.Errorf("unable to generate a request ID, err: %w", err))
+	}
+	return hex.EncodeToString(data[:])
+}
+
+// isValidRequestID returns true if id can be logged and sent back as is.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// requestID returns the ID of the request of ctx, if any.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// logger returns the logger of the request of ctx, which adds the request ID to the logs.
+func (c *serveCommandConfig) logger(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerContextKey).(*zap.Logger); ok {
+		return logger
+	}
+	return c.root.logger
+}
+
+// newLoggingMiddleware identifies every request with a request ID and logs the requests once handled.
+//
+// The request ID is sent back in the X-Request-Id header and the handlers can log with it using the logger of the request context.
+func newLoggingMiddleware(logger *zap.Logger) hutil.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get(requestIDHeader)
+			if !isValidRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(requestIDHeader, id)
+
+			requestLogger := logger.With(zap.String("request_id", id))
+
+			ctx := context.WithValue(req.Context(), requestIDContextKey, id)
+			ctx = context.WithValue(ctx, loggerContextKey, requestLogger)
+
+			u := *req.URL
+			u.Host = ""
+
+			rec := &statusRecorder{ResponseWriter: w}
+
+			start := time.Now()
+			next.ServeHTTP(rec, req.WithContext(ctx))
+			elapsed := time.Since(start)
+
+			if rec.statusCode == 0 {
+				rec.statusCode = http.StatusOK
+			}
+
+			requestLogger.Info("request handled",
+				zap.String("method", req.Method),
+				zap.Stringer("url", &u),
+				zap.Int("status_code", rec.statusCode),
+				zap.Int("response_size", rec.size),
+				zap.Duration("elapsed", elapsed),
+			)
+		})
+	}
+}
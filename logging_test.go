@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogFlags(t *testing.T) {
+	rootCfg, rootCmd := newRootCommand()
+	_, serveCmd := newServeCommandConfig(rootCfg)
+	rootCmd.Subcommands = []*ffcli.Command{serveCmd}
+
+	require.NoError(t, rootCmd.Parse([]string{"-log-level", "warn", "-log-format", "json", "serve"}))
+	require.Equal(t, zapcore.WarnLevel, rootCfg.logLevel)
+	require.Equal(t, logFormatJSON, rootCfg.logFormat)
+
+	require.NoError(t, rootCfg.setupLogger())
+	require.False(t, rootCfg.logger.Core().Enabled(zapcore.InfoLevel))
+	require.True(t, rootCfg.logger.Core().Enabled(zapcore.WarnLevel))
+
+	t.Run("invalid", func(t *testing.T) {
+		rootCfg, rootCmd := newRootCommand()
+		_, serveCmd := newServeCommandConfig(rootCfg)
+		rootCmd.Subcommands = []*ffcli.Command{serveCmd}
+
+		err := rootCmd.Parse([]string{"-log-format", "xml", "serve"})
+		require.ErrorContains(t, err, `invalid log format "xml"`)
+
+		err = rootCmd.Parse([]string{"-log-level", "verbose", "serve"})
+		require.ErrorContains(t, err, `unrecognized level: "verbose"`)
+	})
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	var handledRequestID string
+	handler := newLoggingMiddleware(zap.New(core))(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		handledRequestID = requestID(req.Context())
+		http.Error(w, "teapot", http.StatusTeapot)
+	}))
+
+	testCases := []struct {
+		name      string
+		requestID string
+		generated bool
+	}{
+		{"generated", "", true},
+		{"kept", "a0b1c2-d3e4", false},
+		{"invalid", "foo bar\n", true},
+		{"too-long", strings.Repeat("a", maxRequestIDLength+1), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logs.TakeAll()
+
+			req := httptest.NewRequest(http.MethodGet, "/foo?bar=baz", nil)
+			if tc.requestID != "" {
+				req.Header.Set(requestIDHeader, tc.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(requestIDHeader)
+			require.Equal(t, handledRequestID, id)
+			if tc.generated {
+				require.Len(t, id, 32)
+			} else {
+				require.Equal(t, tc.requestID, id)
+			}
+
+			entries := logs.TakeAll()
+			require.Len(t, entries, 1)
+
+			fields := entries[0].ContextMap()
+			require.Equal(t, "request handled", entries[0].Message)
+			require.Equal(t, id, fields["request_id"])
+			require.Equal(t, "/foo?bar=baz", fields["url"])
+			require.Equal(t, int64(http.StatusTeapot), fields["status_code"])
+			require.Equal(t, int64(len("teapot\n")), fields["response_size"])
+		})
+	}
+}
+
+func TestEvaluateHandlerRequestID(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	c := newTestServeCommandConfig(t)
+	c.root.logger = zap.New(core)
+	handler := newLoggingMiddleware(c.root.logger)(c.routes())
+
+	form := url.Values{
+		"schema": []string{"CREATE TABLE foo(id int)"},
+		"rows":   []string{"10"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set(requestIDHeader, "req-1234")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, "req-1234", rec.Header().Get(requestIDHeader))
+	require.Contains(t, rec.Body.String(), `<div class="error-request-id">Request ID: <code>req-1234</code></div>`)
+
+	// The logs of the handler are correlated with the request
+	entries := logs.FilterMessage("unable to parse evaluate request").All()
+	require.Len(t, entries, 1)
+	require.Equal(t, "req-1234", entries[0].ContextMap()["request_id"])
+
+	t.Run("no-errors", func(t *testing.T) {
+		form.Set("schema", "CREATE TABLE foo(id int PRIMARY KEY)")
+
+		req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NotEmpty(t, rec.Header().Get(requestIDHeader))
+		require.NotContains(t, rec.Body.String(), "error-request-id")
+	})
+}
@@ -6,64 +6,149 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/a-h/templ"
-	"github.com/dustin/go-humanize"
 	"github.com/peterbourgon/ff/v3/ffcli"
 	"github.com/vrischmann/hutil/v3"
 	"go.uber.org/zap"
-	"golang.org/x/exp/constraints"
-	"golang.org/x/text/language"
+	"go.uber.org/zap/zapcore"
 	"golang.org/x/text/message"
 
 	"rischmann.fr/cassandra-partition-calculator/assets"
 	"rischmann.fr/cassandra-partition-calculator/cassandra"
 	"rischmann.fr/cassandra-partition-calculator/cql"
+	"rischmann.fr/cassandra-partition-calculator/examples"
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+	"rischmann.fr/cassandra-partition-calculator/lint"
+	"rischmann.fr/cassandra-partition-calculator/scenario"
 	"rischmann.fr/cassandra-partition-calculator/ui"
 	"rischmann.fr/cassandra-partition-calculator/ui/fragments"
 )
 
 type rootCommandConfig struct {
-	logger *zap.Logger
+	logger    *zap.Logger
+	logLevel  zapcore.Level
+	logFormat logFormat
+
+	// configPath is the path of the config file, no config file is read if empty
+	configPath string
 }
 
 func newRootCommand() (*rootCommandConfig, *ffcli.Command) {
-	logger, err := zap.NewDevelopment()
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	cfg := &rootCommandConfig{
-		logger: logger,
+		logger:    zap.NewNop(),
+		logLevel:  zapcore.InfoLevel,
+		logFormat: logFormatConsole,
 	}
 
+	fs := flag.NewFlagSet("cassandra-partition-calculator", flag.ContinueOnError)
+	fs.StringVar(&cfg.configPath, "config", "", "Path of the config file")
+	fs.TextVar(&cfg.logLevel, "log-level", cfg.logLevel, "Minimum level of the logs, either debug, info, warn or error")
+	fs.Func("log-format", "Format of the logs, either console or json (default console)", func(data string) error {
+		format, err := parseLogFormat(data)
+		if err != nil {
+			return err
+		}
+		cfg.logFormat = format
+		return nil
+	})
+
 	return cfg, &ffcli.Command{
 		Name:       "cassandra-partition-calculator",
 		ShortUsage: "cassandra-partition-calculator [flags] <subcommand>",
+		LongHelp: strings.TrimSpace(`
+The flags of this command and of the serve command can also be set with environment variables
+and with a config file.
+
+The environment variable of a flag is its name in upper case prefixed by CASSANDRA_PARTITION_CALCULATOR_,
+for example CASSANDRA_PARTITION_CALCULATOR_LISTEN_ADDR sets -listen-addr.
+
+The config file contains one flag per line, for example "listen-addr :8080".
+
+The command line has priority over the environment variables which have priority over the config file.
+`),
+		FlagSet: fs,
+		Options: cfg.options(),
 		Exec: func(_ context.Context, _ []string) error {
 			return flag.ErrHelp
 		},
 	}
 }
 
+// setupLogger creates the logger once the flags are parsed.
+func (c *rootCommandConfig) setupLogger() error {
+	logger, err := newLogger(c.logLevel, c.logFormat)
+	if err != nil {
+		return fmt.Errorf("unable to create logger, err: %w", err)
+	}
+	c.logger = logger
+
+	return nil
+}
+
+const (
+	pageTitle   = "Cassandra Partition Calculator"
+	defaultRows = "100000"
+
+	defaultExampleName = "time_series"
+)
+
 type serveCommandConfig struct {
 	root *rootCommandConfig
 
-	listenAddr string
-	baseURL    string
+	listenAddr    string
+	baseURL       string
+	lintConfig    lint.Config
+	thresholds    cassandra.Thresholds
+	scenariosPath string
+	examplesDirs  []string
+
+	// trustForwardedPrefix is true if the X-Forwarded-Prefix header of the requests is used as the base path
+	trustForwardedPrefix bool
+
+	examples []examples.Example
+	// scenarios is nil if saved scenarios are disabled
+	scenarios   *scenario.Store
+	schemaCache *schemaCache
+	metrics     *serverMetrics
+	limits      serverLimits
+
+	tlsCertPath       string
+	tlsKeyPath        string
+	tlsReloadInterval time.Duration
+	// certificates is nil if TLS is disabled
+	certificates *certificateReloader
+
+	authUsersPath     string
+	authTrustedHeader string
+	// ready is true once the server is listening
+	ready atomic.Bool
 }
 
-func newServeCommandConfig(root *rootCommandConfig) *ffcli.Command {
+func newServeCommandConfig(root *rootCommandConfig) (*serveCommandConfig, *ffcli.Command) {
 	cfg := &serveCommandConfig{
-		root:       root,
-		listenAddr: ":8909",
-		baseURL:    "",
+		root:        root,
+		listenAddr:  ":8909",
+		baseURL:     "",
+		lintConfig:  lint.DefaultConfig(),
+		thresholds:  cassandra.DefaultThresholds(),
+		schemaCache: newSchemaCache(1024),
+		metrics:     newServerMetrics(),
+		limits:      defaultServerLimits(),
+
+		tlsReloadInterval: time.Minute,
 	}
 
 	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
@@ -75,51 +160,156 @@ func newServeCommandConfig(root *rootCommandConfig) *ffcli.Command {
 		cfg.listenAddr = data
 		return nil
 	})
-	fs.StringVar(&cfg.baseURL, "base-url", "", "The base URL of the application")
+	fs.Func("base-url", "The base URL of the application, either a full URL or a path, the application is served under its path", func(data string) error {
+		baseURL, err := parseBaseURL(data)
+		if err != nil {
+			return err
+		}
+		cfg.baseURL = baseURL
+		return nil
+	})
+	fs.BoolVar(&cfg.trustForwardedPrefix, "trust-forwarded-prefix", false, "Use the X-Forwarded-Prefix header set by an upstream proxy as the base path of the links, only if the server is not reachable without going through the proxy")
+	fs.StringVar(&cfg.scenariosPath, "scenarios-db", "", "Path of the database file storing the saved scenarios, saving scenarios is disabled if empty")
+	fs.Func("examples-dir", "Directories containing additional example schemas as .cql files, comma separated, can be repeated", func(data string) error {
+		for _, dir := range strings.Split(data, ",") {
+			if dir = strings.TrimSpace(dir); dir != "" {
+				cfg.examplesDirs = append(cfg.examplesDirs, dir)
+			}
+		}
+		return nil
+	})
+	fs.DurationVar(&cfg.limits.readHeaderTimeout, "read-header-timeout", cfg.limits.readHeaderTimeout, "Maximum duration to read the headers of a request")
+	fs.DurationVar(&cfg.limits.readTimeout, "read-timeout", cfg.limits.readTimeout, "Maximum duration to read a request, body included")
+	fs.DurationVar(&cfg.limits.writeTimeout, "write-timeout", cfg.limits.writeTimeout, "Maximum duration to write a response")
+	fs.DurationVar(&cfg.limits.idleTimeout, "idle-timeout", cfg.limits.idleTimeout, "Maximum duration to keep an idle connection open")
+	fs.DurationVar(&cfg.limits.shutdownTimeout, "shutdown-timeout", cfg.limits.shutdownTimeout, "Maximum duration to wait for the requests in flight when shutting down")
+	fs.Int64Var(&cfg.limits.maxBodySize, "max-body-size", cfg.limits.maxBodySize, "Maximum size of a request body in bytes")
+	fs.StringVar(&cfg.tlsCertPath, "tls-cert", "", "Path of the TLS certificate in PEM format, HTTPS is served if set")
+	fs.StringVar(&cfg.tlsKeyPath, "tls-key", "", "Path of the TLS private key in PEM format")
+	fs.DurationVar(&cfg.tlsReloadInterval, "tls-reload-interval", cfg.tlsReloadInterval, "Interval at which the TLS certificate files are checked for changes, 0 disables the reload")
+	fs.StringVar(&cfg.authUsersPath, "auth-users-file", "", "Path of the basic authentication users file, one name:$2y$... bcrypt hash per line as created by htpasswd -B")
+	fs.StringVar(&cfg.authTrustedHeader, "auth-trusted-header", "", "Header containing the user authenticated by a trusted upstream proxy, requests without it are rejected")
+	registerLintFlags(fs, &cfg.lintConfig)
+	registerThresholdFlags(fs, &cfg.thresholds)
 
-	return &ffcli.Command{
+	return cfg, &ffcli.Command{
 		Name:       "serve",
 		ShortUsage: "serve [flags]",
 		ShortHelp:  `serve the UI and API`,
 		FlagSet:    fs,
+		Options:    root.subcommandOptions(),
 		Exec:       cfg.Exec,
 	}
 }
 
 func (c *serveCommandConfig) Exec(ctx context.Context, args []string) error {
-	var middlewares hutil.MiddlewareStack
-	middlewares.Use(hutil.NewLoggingMiddleware(c.root.logger))
+	if err := c.loadExamples(); err != nil {
+		return err
+	}
 
-	mux := http.NewServeMux()
-	mux.Handle("/assets/", assets.FileServer)
-	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
-		schemaCQL := `CREATE TABLE events(
-	tenant_key bigint,
-	user_id uuid,
-	event_category text,
-	event_id timeuuid,
-	event_data blob,
-	PRIMARY KEY ((tenant_key, user_id, event_category), event_id)
-);`
-
-		// TODO(vincent): stop hardcoding this
-		schema := ui.SchemaComponent(c.baseURL, schemaCQL)
-
-		page := ui.MainPage(c.baseURL, "Cassandra Partition Calculator", schema)
-		page.Render(req.Context(), w)
-	})
-	mux.HandleFunc("/evaluate", c.evaluateHandler)
+	if c.scenariosPath != "" {
+		store, err := scenario.Open(c.scenariosPath)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		c.scenarios = store
+	}
+
+	if c.tlsCertPath != "" || c.tlsKeyPath != "" {
+		if c.tlsCertPath == "" || c.tlsKeyPath == "" {
+			return errors.New("both the TLS certificate and the TLS key are required")
+		}
+
+		certificates, err := newCertificateReloader(c.root.logger, c.tlsCertPath, c.tlsKeyPath)
+		if err != nil {
+			return err
+		}
+		c.certificates = certificates
+	}
+
+	handler, err := c.handler()
+	if err != nil {
+		return err
+	}
 
 	c.root.logger.Info("serving UI and API",
 		zap.String("listen_addr", c.listenAddr),
 		zap.String("base_url", c.baseURL),
+		zap.Bool("trust_forwarded_prefix", c.trustForwardedPrefix),
 		zap.String("assets_mode", assets.Mode),
+		zap.String("scenarios_db", c.scenariosPath),
+		zap.Int("examples", len(c.examples)),
+		zap.Int64("max_partition_size", c.thresholds.MaxPartitionBytes),
+		zap.Int64("max_partition_values", c.thresholds.MaxPartitionValues),
+		zap.Bool("tls", c.certificates != nil),
+		zap.Bool("auth_users_file", c.authUsersPath != ""),
+		zap.String("auth_trusted_header", c.authTrustedHeader),
 	)
 
-	return http.ListenAndServe(c.listenAddr, middlewares.Handler(mux))
+	listener, err := net.Listen("tcp", c.listenAddr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	return c.serve(ctx, listener, handler)
 }
 
-var errFieldEmpty = errors.New("field empty")
+// handler returns the routes wrapped in the middlewares.
+func (c *serveCommandConfig) handler() (http.Handler, error) {
+	authMiddleware, err := c.newAuthMiddleware()
+	if err != nil {
+		return nil, err
+	}
+
+	routes := c.routes()
+
+	var middlewares hutil.MiddlewareStack
+	middlewares.Use(newLoggingMiddleware(c.root.logger))
+	middlewares.Use(c.newBasePathMiddleware())
+	middlewares.Use(c.newLanguageMiddleware())
+	middlewares.Use(c.metrics.middleware(routes))
+	middlewares.Use(newSameOriginMiddleware())
+	if authMiddleware != nil {
+		middlewares.Use(authMiddleware)
+	}
+	middlewares.Use(newMaxBodySizeMiddleware(c.limits.maxBodySize))
+
+	return middlewares.Handler(routes), nil
+}
+
+func (c *serveCommandConfig) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/assets/", assets.FileServer)
+	mux.HandleFunc("/", c.indexHandler)
+	mux.HandleFunc("/evaluate", c.evaluateHandler)
+	mux.HandleFunc("/examples", c.examplesHandler)
+	mux.HandleFunc("/compare", c.compareHandler)
+	mux.HandleFunc("/export", c.exportHandler)
+	mux.HandleFunc("/scenarios", c.scenariosHandler)
+	mux.HandleFunc("/scenarios/", c.scenarioHandler)
+	mux.HandleFunc("/api/v1/estimate", c.apiEstimateHandler)
+	mux.HandleFunc("/api/v1/scenarios", c.apiScenariosHandler)
+	mux.HandleFunc("/api/v1/scenarios/", c.apiScenarioHandler)
+	mux.HandleFunc("/api/openapi.json", openAPIHandler)
+	mux.Handle("/metrics", c.metrics.handler())
+	mux.HandleFunc("/healthz", c.healthzHandler)
+	mux.HandleFunc("/readyz", c.readyzHandler)
+
+	return mux
+}
+
+var (
+	errFieldEmpty      = i18n.NewError("field empty")
+	errNoTable         = i18n.NewError("no CREATE TABLE statement found")
+	errUnknownColumn   = i18n.NewError("unknown column")
+	errFixedSizeColumn = i18n.NewError("column has a fixed size")
+	errNegativeValue   = i18n.NewError("value can't be negative")
+)
 
 type validationError struct {
 	field string
@@ -132,13 +322,18 @@ func (e *validationError) Error() string {
 func (e *validationError) Unwrap() error {
 	return e.err
 }
+func (e *validationError) Localize(p *message.Printer) string {
+	return p.Sprintf("field %q is invalid because of error: %s", e.field, i18n.ErrorMessage(p, e.err))
+}
 
 type evaluationSchema struct {
 	rows   int64
 	schema cql.Schema
+	// units are the units the sizes are displayed with
+	units format.Units
 }
 
-func (c *serveCommandConfig) parseEvaluateRequest(req *http.Request) (res evaluationSchema, err error) {
+func (c *serveCommandConfig) parseEvaluateForm(form url.Values) (res evaluationSchema, err error) {
 	// Parse the form data
 	//
 	// From this we get:
@@ -146,11 +341,6 @@ func (c *serveCommandConfig) parseEvaluateRequest(req *http.Request) (res evalua
 	// * the schema
 	// * maybe some size estimates for the columns
 
-	if err = req.ParseForm(); err != nil {
-		return res, fmt.Errorf("unable to parse form, err: %w", err)
-	}
-	form := req.Form
-
 	rowsStr := form.Get("rows")
 	if rowsStr == "" {
 		return res, &validationError{
@@ -166,6 +356,20 @@ func (c *serveCommandConfig) parseEvaluateRequest(req *http.Request) (res evalua
 			err:   err,
 		}
 	}
+	if res.rows < 0 {
+		return res, &validationError{
+			field: "rows",
+			err:   errNegativeValue,
+		}
+	}
+
+	res.units, err = format.ParseUnits(form.Get(unitsParam))
+	if err != nil {
+		return res, &validationError{
+			field: unitsParam,
+			err:   err,
+		}
+	}
 
 	schemaStr := form.Get("schema")
 	if schemaStr == "" {
@@ -175,18 +379,18 @@ func (c *serveCommandConfig) parseEvaluateRequest(req *http.Request) (res evalua
 		}
 	}
 
-	res.schema, err = cql.ParseSchema(schemaStr)
+	res.schema, err = c.parseSchemaField("schema", schemaStr, "")
 	if err != nil {
-		return res, &validationError{
-			field: "schema",
-			err:   err,
-		}
+		return res, err
 	}
 
 	// Parse the size estimates provided in the form
 	//
 	// This is not available in the first submission of the form because it depends
 	// on the schema provided being parsed.
+	//
+	// The estimates of unknown and fixed size columns are ignored: they are left over from a previous
+	// version of the schema, for example when a column is renamed or its type changed.
 
 	for name, value := range form {
 		const prefix = "size::"
@@ -201,92 +405,406 @@ func (c *serveCommandConfig) parseEvaluateRequest(req *http.Request) (res evalua
 				}
 			}
 
-			res.schema = res.schema.
-				WithColumnSizeEstimate(columnName, sizeEstimate)
+			res.schema, err = withColumnSizeEstimate(res.schema, name, columnName, sizeEstimate)
+			if err != nil && !isStaleSizeEstimate(err) {
+				return res, err
+			}
 		}
 	}
 
 	return
 }
 
-func formatInt[T constraints.Integer](language language.Tag, n T) string {
-	printer := message.NewPrinter(language)
-	return printer.Sprintf("%d", n)
+// parseSchemaField parses and validates the schema provided in field.
+//
+// It accepts anything cqlsh DESCRIBE outputs but only returns one table:
+// the one named tableName or the first one if tableName is empty.
+func (c *serveCommandConfig) parseSchemaField(field string, schemaStr string, tableName string) (cql.Schema, error) {
+	schema, err := c.parseSchema(field, schemaStr, tableName)
+	if err != nil {
+		c.metrics.schemaParseFailed()
+	}
+	return schema, err
 }
 
-func formatFloat[T constraints.Float](language language.Tag, n T) string {
-	printer := message.NewPrinter(language)
-	return printer.Sprintf("%f", n)
+func (c *serveCommandConfig) parseSchema(field string, schemaStr string, tableName string) (cql.Schema, error) {
+	tables, _, err := c.schemaCache.parseDescribe(schemaStr)
+	if err != nil {
+		return cql.Schema{}, &validationError{
+			field: field,
+			err:   err,
+		}
+	}
+	if len(tables) == 0 {
+		return cql.Schema{}, &validationError{
+			field: field,
+			err:   errNoTable,
+		}
+	}
+
+	schema := tables[0]
+	if tableName != "" {
+		found := false
+		for _, table := range tables {
+			if table.TableName == tableName || table.QualifiedName() == tableName {
+				schema, found = table, true
+				break
+			}
+		}
+		if !found {
+			return cql.Schema{}, &validationError{
+				field: field,
+				err:   i18n.NewError("table %q: %s", tableName, errNoTable),
+			}
+		}
+	}
+
+	if err := cql.Validate(schema); err != nil {
+		return cql.Schema{}, err
+	}
+
+	return schema, nil
 }
 
-func formatIF[T constraints.Float | constraints.Integer](language language.Tag, n T) string {
-	printer := message.NewPrinter(language)
-	return printer.Sprintf("%v", n)
+// isStaleSizeEstimate returns true if err is caused by the size estimate of a column which
+// doesn't exist or doesn't have a variable size anymore.
+func isStaleSizeEstimate(err error) bool {
+	return errors.Is(err, errUnknownColumn) || errors.Is(err, errFixedSizeColumn)
 }
 
-func (c *serveCommandConfig) evaluateHandler(w http.ResponseWriter, req *http.Request) {
-	// Parse the request
+// withColumnSizeEstimate sets the size estimate of a variable size column.
+// The column name is case insensitive like unquoted CQL identifiers.
+func withColumnSizeEstimate(schema cql.Schema, field string, columnName string, size int) (cql.Schema, error) {
+	for _, column := range schema.Columns {
+		if !strings.EqualFold(column.Name, columnName) {
+			continue
+		}
 
-	languageTag := message.MatchLanguage(req.Header.Get("Accept-Language"), "en")
+		if column.Type.IsFixedSize() {
+			return schema, &validationError{
+				field: field,
+				err:   errFixedSizeColumn,
+			}
+		}
+		if size < 0 {
+			return schema, &validationError{
+				field: field,
+				err:   errNegativeValue,
+			}
+		}
 
-	// Parse the form data
+		return schema.WithColumnSizeEstimate(column.Name, size), nil
+	}
+
+	return schema, &validationError{
+		field: field,
+		err:   errUnknownColumn,
+	}
+}
+
+func (c *serveCommandConfig) indexHandler(w http.ResponseWriter, req *http.Request) {
+	example := c.defaultExample()
+	form := ui.FormData{
+		Schema:  example.Schema,
+		Rows:    defaultRows,
+		Example: example.Name,
+	}
+
+	// Restore the calculation of a permalink
+
+	if encoded := req.URL.Query().Get(permalinkStateParam); encoded != "" {
+		state, err := decodePermalinkState(encoded)
+		if err != nil {
+			c.logger(req.Context()).Error("unable to decode permalink", zap.Error(err))
+
+			form.Results.ErrorMessages = errorMessages(req.Context(), err)
+			c.renderPage(w, req, http.StatusBadRequest, form)
+			return
+		}
+
+		c.renderPage(w, req, http.StatusOK, ui.FormData{
+			Schema:  state.Schema,
+			Rows:    state.Rows,
+			Units:   state.Units,
+			Results: c.evaluate(req.Context(), state.Form()),
+		})
+		return
+	}
+
+	// Load an example
+
+	if name := req.URL.Query().Get("example"); name != "" {
+		example, ok := examples.Find(c.examples, name)
+		if !ok {
+			form.Results.ErrorMessages = errorMessages(req.Context(), i18n.NewError("unknown example %q", name))
+			c.renderPage(w, req, http.StatusNotFound, form)
+			return
+		}
+
+		form.Schema = example.Schema
+		form.Example = example.Name
+	}
+
+	c.renderPage(w, req, http.StatusOK, form)
+}
 
-	res, err := c.parseEvaluateRequest(req)
+// defaultExample returns the example displayed when the calculator is opened.
+// It's the time series example unless it has been replaced by a custom one with the same name.
+func (c *serveCommandConfig) defaultExample() examples.Example {
+	if example, ok := examples.Find(c.examples, defaultExampleName); ok {
+		return example
+	}
+	if len(c.examples) > 0 {
+		return c.examples[0]
+	}
+	return examples.Example{}
+}
+
+// examplesHandler returns the schema input filled with the example selected in the gallery.
+func (c *serveCommandConfig) examplesHandler(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+
+	name := req.URL.Query().Get("example")
+
+	// Without htmx the whole page is rendered with the example
+	if !isHTMXRequest(req) {
+		http.Redirect(w, req, c.baseURLOf(req.Context())+"/?example="+url.QueryEscape(name), http.StatusSeeOther)
+		return
+	}
+
+	example, ok := examples.Find(c.examples, name)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	component := ui.SchemaInput(example.Schema)
+	component.Render(req.Context(), w)
+}
+
+// loadExamples loads the built-in examples and the examples of the examples directories, if any.
+// The examples of a directory replace the examples with the same name loaded before.
+func (c *serveCommandConfig) loadExamples() error {
+	builtins, err := examples.Load(examples.FS)
 	if err != nil {
-		c.root.logger.Error("unable to parse evaluate request", zap.Error(err))
+		return fmt.Errorf("unable to load the built-in examples, err: %w", err)
+	}
+	c.examples = builtins
 
-		if isHTMXRequest(req) {
-			component := fragments.Results(fragments.ResultsData{
-				ErrorMessages: []string{err.Error()},
-			})
-			component.Render(req.Context(), w)
+	for _, dir := range c.examplesDirs {
+		extra, err := examples.Load(os.DirFS(dir))
+		if err != nil {
+			return fmt.Errorf("unable to load the examples of %q, err: %w", dir, err)
+		}
+		c.examples = examples.Merge(c.examples, extra)
+	}
 
-		} else {
-			// TODO(vincent): flash message
-			http.Redirect(w, req, "/", http.StatusTemporaryRedirect)
+	return nil
+}
+
+func (c *serveCommandConfig) evaluateHandler(w http.ResponseWriter, req *http.Request) {
+	var (
+		data   fragments.ResultsData
+		status = http.StatusOK
+	)
+	if err := req.ParseForm(); err != nil {
+		c.logger(req.Context()).Error("unable to parse form", zap.Error(err))
+
+		data.ErrorMessages = []string{i18n.T(req.Context(), "unable to parse form, err: %s", err)}
+		if isBodyTooLarge(err) {
+			status = http.StatusRequestEntityTooLarge
+			data.ErrorMessages = errorMessages(req.Context(), c.formTooLargeError(req.Context()))
 		}
+	} else {
+		data = c.evaluate(req.Context(), req.Form)
+	}
+	if len(data.ErrorMessages) > 0 {
+		data.RequestID = requestID(req.Context())
+	}
 
+	// With htmx only the results are swapped in the page.
+	if isHTMXRequest(req) {
+		component := fragments.Results(data)
+		component.Render(req.Context(), w)
 		return
 	}
 
+	// Without JavaScript the whole page is rendered again with the submitted form.
+
+	form := ui.FormData{
+		Schema:   req.Form.Get("schema"),
+		Rows:     req.Form.Get("rows"),
+		Units:    req.Form.Get(unitsParam),
+		Results:  data,
+		Scenario: scenarioFormData(req.Form),
+	}
+	if form.Rows == "" {
+		form.Rows = defaultRows
+	}
+
+	c.renderPage(w, req, status, form)
+}
+
+// renderPage renders the main page with the form.
+// The status is replaced by 422 if the results contain errors.
+func (c *serveCommandConfig) renderPage(w http.ResponseWriter, req *http.Request, status int, form ui.FormData) {
+	if status == http.StatusOK && len(form.Results.ErrorMessages) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	form.ScenariosEnabled = c.scenarios != nil
+	form.Examples = c.examples
+	form.LintRules = c.lintRules()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	baseURL := c.baseURLOf(req.Context())
+	page := ui.MainPage(baseURL, pageTitle, c.languageLinks(req), ui.SchemaComponent(baseURL, form))
+	page.Render(req.Context(), w)
+}
+
+// lintRules returns every lint rule, marking the ones disabled by the configuration.
+func (c *serveCommandConfig) lintRules() []fragments.LintRule {
+	res := make([]fragments.LintRule, 0, len(lint.Rules))
+	for _, rule := range lint.Rules {
+		res = append(res, fragments.LintRule{
+			Rule:     rule,
+			Disabled: c.lintConfig.Disabled[rule.Name],
+		})
+	}
+	return res
+}
+
+// evaluate parses the evaluate form and estimates the partition size of the schema.
+// Errors are returned in the result data to be displayed next to the form.
+func (c *serveCommandConfig) evaluate(ctx context.Context, form url.Values) fragments.ResultsData {
+	if c.isKeyspaceForm(form) {
+		return c.evaluateKeyspace(ctx, form)
+	}
+
+	// Parse the form data
+
+	res, err := c.parseEvaluateForm(form)
+	if err != nil {
+		// Invalid schemas are expected while typing, they are not errors of the server
+		c.logger(ctx).Debug("unable to parse evaluate request", zap.Error(err))
+
+		return fragments.ResultsData{
+			ErrorMessages: errorMessages(ctx, err),
+			SchemaErrors:  schemaErrors(ctx, err),
+			SizeEstimates: formSizeEstimates(form),
+		}
+	}
+
 	// Get an estimation
 
-	estimation, err := cassandra.Estimate(res.schema, res.rows)
+	estimation, err := c.metrics.estimate(res.schema, res.rows)
 	if err != nil {
-		c.root.logger.Error("unable to estimate", zap.Error(err))
+		c.logger(ctx).Error("unable to estimate", zap.Error(err))
 
-		if isHTMXRequest(req) {
-			component := fragments.Results(fragments.ResultsData{
-				ErrorMessages: []string{err.Error()},
-			})
-			component.Render(req.Context(), w)
-		} else {
-			// TODO(vincent): flash message
-			http.Redirect(w, req, "/", http.StatusTemporaryRedirect)
+		return fragments.ResultsData{
+			ErrorMessages: errorMessages(ctx, err),
 		}
+	}
 
-		return
+	formatter := newFormatter(ctx, res.units)
+
+	data := fragments.ResultsData{
+		Estimation: fragments.Estimation{
+			Values: formatter.Number(int64(estimation.Values)),
+			Bytes:  formatter.DetailedBytes(int64(estimation.Bytes)),
+		},
+		Schema:   res.schema,
+		Findings: lint.Run(c.lintConfig, res.schema),
 	}
+	c.addResultLinks(ctx, &data, formatter, form, chartTables([]cql.Schema{res.schema}, res.rows))
 
-	// Render the results
+	return data
+}
 
-	if isHTMXRequest(req) {
-		estimation := fragments.Estimation{
-			Values: formatIF(languageTag, estimation.Values),
-			Bytes:  fmt.Sprintf("%s bytes (%s)", formatIF(languageTag, estimation.Bytes), humanize.IBytes(uint64(estimation.Bytes))),
+// addResultLinks adds the permalink, the export links and the charts of the calculation of form to data.
+// They are not essential to the results so errors are only logged.
+func (c *serveCommandConfig) addResultLinks(ctx context.Context, data *fragments.ResultsData, formatter format.Formatter, form url.Values, tables []chartTable) {
+	state := newPermalinkState(form)
+
+	permalink, err := c.permalinkURL(ctx, state)
+	if err != nil {
+		c.logger(ctx).Error("unable to create permalink", zap.Error(err))
+	}
+	data.Permalink = permalink
+
+	data.Exports, err = c.exportLinks(ctx, state)
+	if err != nil {
+		c.logger(ctx).Error("unable to create export links", zap.Error(err))
+	}
+
+	for _, metric := range []chartMetric{chartMetricBytes, chartMetricValues} {
+		chart, err := partitionChart(ctx, formatter, c.thresholds, metric, tables)
+		if err != nil {
+			c.logger(ctx).Error("unable to create chart", zap.String("metric", string(metric)), zap.Error(err))
+			continue
 		}
+		data.Charts = append(data.Charts, chart.String())
+	}
+}
 
-		component := fragments.Results(fragments.ResultsData{
-			Estimation: estimation,
-			Schema:     res.schema,
-		})
-		component.Render(req.Context(), w)
+// formSizeEstimates returns the size estimates of the form sorted by column name.
+func formSizeEstimates(form url.Values) []fragments.SizeEstimate {
+	sizeEstimates := sizeEstimatesFromForm(form)
 
-		return
-	} else {
-		// TODO(vincent): redirect + session storage ?
-		_ = req
+	res := make([]fragments.SizeEstimate, 0, len(sizeEstimates))
+	for column, size := range sizeEstimates {
+		res = append(res, fragments.SizeEstimate{Column: column, Size: size})
 	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Column < res[j].Column })
+
+	return res
+}
+
+// errorMessages returns the messages to display for err, one per schema violation if there are any.
+func errorMessages(ctx context.Context, err error) []string {
+	var violations cql.Violations
+	if errors.As(err, &violations) {
+		res := make([]string, 0, len(violations))
+		for _, violation := range violations {
+			res = append(res, fmt.Sprintf("%s: %s", violation.Pos, i18n.Message(ctx, violation)))
+		}
+		return res
+	}
+
+	return []string{i18n.ErrorMessage(i18n.Printer(ctx), err)}
+}
+
+// schemaErrors returns the errors of err located in the schema.
+func schemaErrors(ctx context.Context, err error) []fragments.SchemaError {
+	var violations cql.Violations
+	if errors.As(err, &violations) {
+		res := make([]fragments.SchemaError, 0, len(violations))
+		for _, violation := range violations {
+			res = append(res, fragments.SchemaError{
+				Message: i18n.Message(ctx, violation),
+				Line:    violation.Pos.Line,
+				Column:  violation.Pos.Column,
+			})
+		}
+		return res
+	}
+
+	var syntaxErr *cql.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return []fragments.SchemaError{{
+			Message:   i18n.ErrorMessage(i18n.Printer(ctx), syntaxErr.Err),
+			Line:      syntaxErr.Pos.Line,
+			Column:    syntaxErr.Pos.Column,
+			EndLine:   syntaxErr.End.Line,
+			EndColumn: syntaxErr.End.Column,
+		}}
+	}
+
+	return nil
 }
 
 func isHTMXRequest(req *http.Request) bool {
@@ -311,18 +829,32 @@ var _ templ.Component = (concatComponents)(nil)
 func main() {
 	var (
 		rootCfg, rootCmd = newRootCommand()
-		serveCmd         = newServeCommandConfig(rootCfg)
-		evaluateCmd      = newEvaluateCommandConfig(rootCfg)
+		_, serveCmd      = newServeCommandConfig(rootCfg)
+		_, evaluateCmd   = newEvaluateCommandConfig(rootCfg)
+		lintCmd          = newLintCommandConfig(rootCfg)
+		fmtCmd           = newFmtCommandConfig(rootCfg)
+		importCmd        = newImportCommandConfig(rootCfg)
 	)
 
 	rootCmd.Subcommands = []*ffcli.Command{
 		serveCmd,
 		evaluateCmd,
+		lintCmd,
+		fmtCmd,
+		importCmd,
 	}
 
 	//
 
-	err := rootCmd.ParseAndRun(context.Background(), os.Args[1:])
+	err := rootCmd.Parse(os.Args[1:])
+	if err == nil {
+		err = rootCfg.setupLogger()
+	}
+	if err == nil {
+		err = rootCmd.Run(context.Background())
+		_ = rootCfg.logger.Sync()
+	}
+
 	switch {
 	case errors.Is(err, flag.ErrHelp):
 		os.Exit(1)
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+)
+
+// permalinkStateParam is the query parameter of the root page holding a calculation.
+const permalinkStateParam = "state"
+
+// maxPermalinkStateSize is the maximum size of a decoded permalink state.
+// It protects the server from decompressing arbitrarily large payloads.
+const maxPermalinkStateSize = 1 << 20
+
+var errInvalidPermalink = i18n.NewError("invalid permalink")
+
+// permalinkState is everything needed to restore a calculation: the form inputs.
+//
+// The field names are short to keep the URL short.
+type permalinkState struct {
+	Schema        string         `json:"s"`
+	Rows          string         `json:"r,omitempty"`
+	SizeEstimates map[string]int `json:"e,omitempty"`
+	Units         string         `json:"u,omitempty"`
+	// TableRows are the numbers of rows of the tables of a keyspace, by table key
+	TableRows map[string]int64 `json:"t,omitempty"`
+}
+
+// newPermalinkState extracts the state of a calculation from the evaluate form.
+func newPermalinkState(form url.Values) permalinkState {
+	return permalinkState{
+		Schema:        form.Get("schema"),
+		Rows:          form.Get("rows"),
+		SizeEstimates: sizeEstimatesFromForm(form),
+		Units:         form.Get(unitsParam),
+		TableRows:     tableRowsFromForm(form),
+	}
+}
+
+// sizeEstimatesFromForm returns the valid size estimates of the evaluate form, or nil if there are none.
+func sizeEstimatesFromForm(form url.Values) map[string]int {
+	var res map[string]int
+
+	for name, value := range form {
+		const prefix = "size::"
+		if !strings.HasPrefix(name, prefix) || len(value) == 0 {
+			continue
+		}
+
+		size, err := strconv.Atoi(value[0])
+		if err != nil {
+			continue
+		}
+
+		if res == nil {
+			res = make(map[string]int)
+		}
+		res[name[len(prefix):]] = size
+	}
+
+	return res
+}
+
+// Form returns the evaluate form the state was created from.
+func (s permalinkState) Form() url.Values {
+	res := url.Values{
+		"schema": {s.Schema},
+		"rows":   {s.Rows},
+	}
+	if s.Units != "" {
+		res.Set(unitsParam, s.Units)
+	}
+
+	names := make([]string, 0, len(s.SizeEstimates))
+	for name := range s.SizeEstimates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		res.Set("size::"+name, strconv.Itoa(s.SizeEstimates[name]))
+	}
+
+	for table, rows := range s.TableRows {
+		res.Set(tableRowsPrefix+table, strconv.FormatInt(rows, 10))
+	}
+
+	return res
+}
+
+// Encode returns the state compressed and encoded with URL safe base64.
+func (s permalinkState) Encode() (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal permalink state, err: %w", err)
+	}
+
+	var buf bytes.Buffer
+
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", fmt.Errorf("unable to create compressor, err: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", fmt.Errorf("unable to compress permalink state, err: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("unable to compress permalink state, err: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodePermalinkState is the inverse of permalinkState.Encode.
+func decodePermalinkState(encoded string) (res permalinkState, err error) {
+	compressed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return res, i18n.NewError("%s: %s", errInvalidPermalink, err)
+	}
+
+	r := flate.NewReader(bytes.NewReader(compressed))
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxPermalinkStateSize+1))
+	if err != nil {
+		return res, i18n.NewError("%s: %s", errInvalidPermalink, err)
+	}
+	if len(data) > maxPermalinkStateSize {
+		return res, i18n.NewError("%s: %s", errInvalidPermalink, i18n.NewError("state is too large"))
+	}
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		return res, i18n.NewError("%s: %s", errInvalidPermalink, err)
+	}
+
+	return res, nil
+}
+
+// permalinkURL returns the URL of the root page restoring the state.
+func (c *serveCommandConfig) permalinkURL(ctx context.Context, state permalinkState) (string, error) {
+	encoded, err := state.Encode()
+	if err != nil {
+		return "", err
+	}
+
+	return c.baseURLOf(ctx) + "/?" + permalinkStateParam + "=" + encoded, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermalinkStateRoundTrip(t *testing.T) {
+	form := url.Values{
+		"schema":           {"CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));"},
+		"rows":             {"1000"},
+		"size::event_data": {"100"},
+	}
+
+	state := newPermalinkState(form)
+	require.Equal(t, map[string]int{"event_data": 100}, state.SizeEstimates)
+
+	encoded, err := state.Encode()
+	require.NoError(t, err)
+	require.Equal(t, url.QueryEscape(encoded), encoded, "encoded state must be URL safe")
+
+	decoded, err := decodePermalinkState(encoded)
+	require.NoError(t, err)
+	require.Equal(t, state, decoded)
+	require.Equal(t, form, decoded.Form())
+
+	// The units are optional
+	form.Set(unitsParam, "MiB")
+
+	encoded, err = newPermalinkState(form).Encode()
+	require.NoError(t, err)
+
+	decoded, err = decodePermalinkState(encoded)
+	require.NoError(t, err)
+	require.Equal(t, "MiB", decoded.Units)
+	require.Equal(t, form, decoded.Form())
+}
+
+func TestDecodePermalinkStateErrors(t *testing.T) {
+	compress := func(data []byte) string {
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.BestCompression)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		return base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	}
+
+	testCases := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not compressed")),
+		compress([]byte("not json")),
+		compress(bytes.Repeat([]byte(" "), maxPermalinkStateSize+1)),
+	}
+
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			_, err := decodePermalinkState(tc)
+			require.ErrorIs(t, err, errInvalidPermalink)
+		})
+	}
+}
+
+func TestIndexHandlerPermalink(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	// Get a permalink from the evaluate handler
+
+	form := url.Values{
+		"schema":     {"CREATE TABLE users(user_id uuid PRIMARY KEY, name text);"},
+		"rows":       {"42"},
+		"size::name": {"30"},
+	}
+
+	rec := doEvaluate(t, c, form, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	start := strings.Index(body, `id="permalink" href="`)
+	require.NotEqual(t, -1, start)
+	body = body[start+len(`id="permalink" href="`):]
+	permalink := body[:strings.Index(body, `"`)]
+
+	// Restore it
+
+	req := httptest.NewRequest(http.MethodGet, permalink, nil)
+	rec = httptest.NewRecorder()
+	c.indexHandler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body = rec.Body.String()
+	require.Contains(t, body, "CREATE TABLE users")
+	require.Contains(t, body, `value="42"`)
+	require.Contains(t, body, `name="size::name" value="30"`)
+	require.Contains(t, body, "Partition size")
+}
+
+func TestIndexHandlerInvalidPermalink(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/?state=foobar", nil)
+	rec := httptest.NewRecorder()
+	c.indexHandler(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), `class="error-message"`)
+	require.Contains(t, rec.Body.String(), "CREATE TABLE events")
+}
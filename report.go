@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"rischmann.fr/cassandra-partition-calculator/assets"
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+	"rischmann.fr/cassandra-partition-calculator/cql"
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+	"rischmann.fr/cassandra-partition-calculator/lint"
+	"rischmann.fr/cassandra-partition-calculator/ui"
+	"rischmann.fr/cassandra-partition-calculator/ui/fragments"
+)
+
+type reportFormat string
+
+const (
+	reportFormatCSV      reportFormat = "csv"
+	reportFormatMarkdown reportFormat = "markdown"
+	reportFormatHTML     reportFormat = "html"
+)
+
+var reportFormats = []reportFormat{
+	reportFormatCSV,
+	reportFormatMarkdown,
+	reportFormatHTML,
+}
+
+func parseReportFormat(data string) (reportFormat, error) {
+	for _, format := range reportFormats {
+		if data == string(format) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("invalid report format %q, expected csv, markdown or html", data)
+}
+
+func (f reportFormat) contentType() string {
+	switch f {
+	case reportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case reportFormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "text/html; charset=utf-8"
+	}
+}
+
+func (f reportFormat) extension() string {
+	switch f {
+	case reportFormatCSV:
+		return ".csv"
+	case reportFormatMarkdown:
+		return ".md"
+	default:
+		return ".html"
+	}
+}
+
+// reportTable is the estimation of a table in a report.
+type reportTable struct {
+	// source is the file the table was read from, if any
+	source     string
+	schema     cql.Schema
+	rows       int64
+	estimation cassandra.Estimation
+	findings   []lint.Finding
+}
+
+type report struct {
+	title  string
+	tables []reportTable
+	// units are the units of the sizes of the Markdown and HTML reports, the CSV report has sizes in bytes
+	units format.Units
+}
+
+func newReportTable(lintConfig lint.Config, schema cql.Schema, rows int64) (reportTable, error) {
+	estimation, err := cassandra.Estimate(schema, rows)
+	if err != nil {
+		return reportTable{}, err
+	}
+
+	return reportTable{
+		schema:     schema,
+		rows:       rows,
+		estimation: estimation,
+		findings:   lint.Run(lintConfig, schema),
+	}, nil
+}
+
+// reportSizeEstimates returns the size estimates of the variable size columns of schema.
+func reportSizeEstimates(formatter format.Formatter, schema cql.Schema) []ui.ReportSizeEstimate {
+	var res []ui.ReportSizeEstimate
+	for _, column := range schema.Columns {
+		if column.Type.IsFixedSize() {
+			continue
+		}
+
+		res = append(res, ui.ReportSizeEstimate{
+			Column: column.Name,
+			Type:   column.Type.Name,
+			Size:   formatter.DetailedBytes(int64(column.Size())),
+		})
+	}
+	return res
+}
+
+// data returns the report formatted for the Markdown and HTML reports.
+// The metrics are the same as in the comparison of calculations.
+func (r report) data() ui.ReportData {
+	res := ui.ReportData{Title: r.title}
+
+	// Reports are not translated
+	formatter := format.New(message.NewPrinter(language.English), r.units)
+
+	for _, table := range r.tables {
+		data := ui.ReportTable{
+			Name:          table.schema.QualifiedName(),
+			Source:        table.source,
+			Schema:        strings.TrimSpace(cql.Format(table.schema)),
+			SizeEstimates: reportSizeEstimates(formatter, table.schema),
+			Findings:      table.findings,
+		}
+
+		for i, value := range compareMetrics(table.rows, table.estimation) {
+			metric := ui.ReportMetric{Name: compareMetricNames[i]}
+			if i < 2 {
+				metric.Value = formatter.Number(value)
+			} else {
+				metric.Value = formatter.DetailedBytes(value)
+			}
+			data.Metrics = append(data.Metrics, metric)
+		}
+
+		res.Tables = append(res.Tables, data)
+	}
+
+	return res
+}
+
+func (r report) write(ctx context.Context, w io.Writer, format reportFormat) error {
+	switch format {
+	case reportFormatCSV:
+		return r.writeCSV(w)
+	case reportFormatMarkdown:
+		return r.writeMarkdown(w)
+	default:
+		return r.writeHTML(ctx, w)
+	}
+}
+
+// writeCSV writes one line per table, sizes are in bytes.
+func (r report) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	_ = cw.Write([]string{
+		"source", "table", "partition_key", "clustering_key", "size_estimates",
+		"rows", "values", "partition_bytes", "partition_key_bytes", "clustering_key_bytes", "metadata_bytes", "rows_bytes",
+		"warnings",
+	})
+
+	for _, table := range r.tables {
+		sizeEstimates := make(map[string]int)
+		for _, column := range table.schema.Columns {
+			if !column.Type.IsFixedSize() {
+				sizeEstimates[column.Name] = column.Size()
+			}
+		}
+
+		warnings := make([]string, 0, len(table.findings))
+		for _, finding := range table.findings {
+			warnings = append(warnings, finding.String())
+		}
+
+		record := []string{
+			table.source,
+			table.schema.QualifiedName(),
+			table.schema.PrimaryKey.PartitionKey.String(),
+			table.schema.PrimaryKey.ClusteringKey.String(),
+			formatSizeEstimatesList(sizeEstimates),
+		}
+		for _, value := range compareMetrics(table.rows, table.estimation) {
+			record = append(record, strconv.FormatInt(value, 10))
+		}
+		record = append(record, strings.Join(warnings, "; "))
+
+		_ = cw.Write(record)
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// markdownEscaper escapes the characters with a meaning in a Markdown table cell.
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\n", " ")
+
+func (r report) writeMarkdown(w io.Writer) error {
+	var sb strings.Builder
+
+	data := r.data()
+
+	fmt.Fprintf(&sb, "# %s\n", data.Title)
+
+	for _, table := range data.Tables {
+		fmt.Fprintf(&sb, "\n## %s\n\n", table.Name)
+		if table.Source != "" {
+			fmt.Fprintf(&sb, "Source: `%s`\n\n", table.Source)
+		}
+
+		fmt.Fprintf(&sb, "```cql\n%s\n```\n", table.Schema)
+
+		if len(table.SizeEstimates) > 0 {
+			sb.WriteString("\n### Size estimates\n\n")
+			sb.WriteString("| Column | Type | Size |\n")
+			sb.WriteString("| --- | --- | ---: |\n")
+			for _, sizeEstimate := range table.SizeEstimates {
+				fmt.Fprintf(&sb, "| `%s` | %s | %s |\n",
+					markdownEscaper.Replace(sizeEstimate.Column),
+					markdownEscaper.Replace(sizeEstimate.Type),
+					sizeEstimate.Size,
+				)
+			}
+		}
+
+		sb.WriteString("\n### Estimation\n\n")
+		sb.WriteString("| Metric | Value |\n")
+		sb.WriteString("| --- | ---: |\n")
+		for _, metric := range table.Metrics {
+			fmt.Fprintf(&sb, "| %s | %s |\n", metric.Name, metric.Value)
+		}
+
+		if len(table.Findings) > 0 {
+			sb.WriteString("\n### Warnings\n\n")
+			for _, finding := range table.Findings {
+				fmt.Fprintf(&sb, "- **%s** (%s)", finding.Rule, finding.Severity)
+				if finding.Column != "" {
+					fmt.Fprintf(&sb, " `%s`", finding.Column)
+				}
+				fmt.Fprintf(&sb, ": %s\n", finding.Message)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (r report) writeHTML(ctx context.Context, w io.Writer) error {
+	style, err := fs.ReadFile(assets.FS, "style.css")
+	if err != nil {
+		return fmt.Errorf("unable to read the report style, err: %w", err)
+	}
+
+	return ui.Report(string(style), r.data()).Render(ctx, w)
+}
+
+// exportLinks returns the links exporting the calculation of state in every report format.
+func (c *serveCommandConfig) exportLinks(ctx context.Context, state permalinkState) ([]fragments.Export, error) {
+	encoded, err := state.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	printer := i18n.Printer(ctx)
+	labels := map[reportFormat]string{
+		reportFormatCSV:      "CSV",
+		reportFormatMarkdown: "Markdown",
+		reportFormatHTML:     "HTML report",
+	}
+
+	res := make([]fragments.Export, 0, len(reportFormats))
+	for _, format := range reportFormats {
+		res = append(res, fragments.Export{
+			Label: printer.Sprintf(labels[format]),
+			URL:   c.baseURLOf(ctx) + "/export?format=" + string(format) + "&" + permalinkStateParam + "=" + encoded,
+		})
+	}
+
+	return res, nil
+}
+
+// exportHandler downloads the report of the calculation of a permalink state.
+func (c *serveCommandConfig) exportHandler(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+
+	query := req.URL.Query()
+
+	format, err := parseReportFormat(query.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	state, err := decodePermalinkState(query.Get(permalinkStateParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	form := state.Form()
+
+	// A schema with several tables is exported with every table
+	var calculations []evaluationSchema
+	if c.isKeyspaceForm(form) {
+		calculations, err = c.parseKeyspaceForm(form)
+	} else {
+		var calculation evaluationSchema
+		calculation, err = c.parseEvaluateForm(form)
+		calculations = []evaluationSchema{calculation}
+	}
+	if err != nil {
+		http.Error(w, strings.Join(errorMessages(req.Context(), err), "\n"), http.StatusUnprocessableEntity)
+		return
+	}
+
+	r := report{
+		units: calculations[0].units,
+	}
+	for _, calculation := range calculations {
+		estimation, err := c.metrics.estimate(calculation.schema, calculation.rows)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		r.tables = append(r.tables, reportTable{
+			schema:     calculation.schema,
+			rows:       calculation.rows,
+			estimation: estimation,
+			findings:   lint.Run(c.lintConfig, calculation.schema),
+		})
+	}
+
+	name := r.tables[0].schema.QualifiedName()
+	r.title = "Partition size of " + name
+	if len(r.tables) > 1 {
+		name = r.tables[0].schema.Keyspace
+		if name == "" {
+			name = "tables"
+		}
+		r.title = fmt.Sprintf("Partition sizes of %d tables", len(r.tables))
+	}
+
+	var buf bytes.Buffer
+	if err := r.write(req.Context(), &buf, format); err != nil {
+		c.logger(req.Context()).Error("unable to write report", zap.String("format", string(format)), zap.Error(err))
+		http.Error(w, "unable to write the report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.contentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": name + format.extension(),
+	}))
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/lint"
+)
+
+func newTestReport(t testing.TB) report {
+	schema, err := cql.ParseSchema("CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));")
+	require.NoError(t, err)
+
+	table, err := newReportTable(lint.DefaultConfig(), schema.WithColumnSizeEstimate("event_data", 100), 1000)
+	require.NoError(t, err)
+	table.source = "events.cql"
+
+	return report{
+		title:  "Partition sizes",
+		tables: []reportTable{table},
+	}
+}
+
+func TestReportCSV(t *testing.T) {
+	r := newTestReport(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.write(context.Background(), &buf, reportFormatCSV))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	record := make(map[string]string)
+	for i, name := range records[0] {
+		record[name] = records[1][i]
+	}
+
+	require.Equal(t, "events.cql", record["source"])
+	require.Equal(t, "events", record["table"])
+	require.Equal(t, "(user_id)", record["partition_key"])
+	require.Equal(t, "event_data=100", record["size_estimates"])
+	require.Equal(t, "1000", record["rows"])
+	require.Equal(t, "1000", record["values"])
+	require.Equal(t, "132032", record["partition_bytes"])
+	require.Equal(t, "", record["warnings"])
+}
+
+func TestReportMarkdown(t *testing.T) {
+	r := newTestReport(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.write(context.Background(), &buf, reportFormatMarkdown))
+
+	body := buf.String()
+	require.True(t, strings.HasPrefix(body, "# Partition sizes\n\n## events\n"))
+	require.Contains(t, body, "```cql\nCREATE TABLE events (")
+	require.Contains(t, body, "| `event_data` | blob | 100 bytes (100 B) |\n")
+	require.Contains(t, body, "| Rows | 1,000 |\n")
+	require.Contains(t, body, "| Partition size | 132,032 bytes (129 KiB) |\n")
+	require.NotContains(t, body, "### Warnings")
+
+	// Sizes are formatted with the units of the report
+	r.units = format.SI
+	buf.Reset()
+	require.NoError(t, r.write(context.Background(), &buf, reportFormatMarkdown))
+	require.Contains(t, buf.String(), "| Partition size | 132,032 bytes (132 kB) |\n")
+}
+
+func TestReportHTML(t *testing.T) {
+	r := newTestReport(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.write(context.Background(), &buf, reportFormatHTML))
+
+	body := buf.String()
+	require.True(t, strings.HasPrefix(body, "<!doctype html>"))
+	require.Contains(t, body, "<title>Partition sizes</title>")
+	// The style is inlined, the report doesn't link to the assets
+	require.Contains(t, body, ".lint-finding")
+	require.NotContains(t, body, "/assets/")
+	require.Contains(t, body, "<td><code>event_data</code></td>")
+}
+
+func TestExportHandler(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+	handler := c.routes()
+
+	state := permalinkState{
+		Schema:        "CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));",
+		Rows:          "1000",
+		SizeEstimates: map[string]int{"event_data": 100},
+	}
+	encoded, err := state.Encode()
+	require.NoError(t, err)
+
+	for _, format := range reportFormats {
+		rec := doRequest(t, handler, http.MethodGet, "/export?format="+string(format)+"&state="+encoded, "", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.Equal(t, format.contentType(), rec.Header().Get("Content-Type"))
+		require.Equal(t, `attachment; filename=events`+format.extension(), rec.Header().Get("Content-Disposition"))
+		require.Contains(t, rec.Body.String(), "event_data")
+	}
+
+	// The results link to the exports
+	rec := doEvaluate(t, c, state.Form(), true)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `href="/export?format=csv&amp;state=`+encoded+`"`)
+
+	// Errors
+	rec = doRequest(t, handler, http.MethodGet, "/export?format=pdf&state="+encoded, "", "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, handler, http.MethodGet, "/export?format=csv&state=foo", "", "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// The units of the calculation are kept
+	state.Units = "MiB"
+	encoded, err = state.Encode()
+	require.NoError(t, err)
+	rec = doRequest(t, handler, http.MethodGet, "/export?format=markdown&state="+encoded, "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "| Partition size | 132,032 bytes (0.13 MiB) |\n")
+
+	invalid, err := permalinkState{Schema: "CREATE TABLE events(", Rows: "10"}.Encode()
+	require.NoError(t, err)
+	rec = doRequest(t, handler, http.MethodGet, "/export?format=csv&state="+url.QueryEscape(invalid), "", "")
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestReportWarnings(t *testing.T) {
+	schema, err := cql.ParseSchema("CREATE TABLE blobs(id blob, data blob, PRIMARY KEY (id));")
+	require.NoError(t, err)
+
+	table, err := newReportTable(lint.DefaultConfig(), schema, 1)
+	require.NoError(t, err)
+	require.NotEmpty(t, table.findings)
+
+	r := report{title: "Blobs", tables: []reportTable{table}}
+
+	var buf bytes.Buffer
+	require.NoError(t, r.write(context.Background(), &buf, reportFormatMarkdown))
+	require.Contains(t, buf.String(), "### Warnings\n\n- **"+table.findings[0].Rule+"** (warning) `id`: ")
+
+	buf.Reset()
+	require.NoError(t, r.write(context.Background(), &buf, reportFormatCSV))
+	require.Contains(t, buf.String(), table.findings[0].String())
+}
@@ -0,0 +1,204 @@
+package scenario
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Scenario is a named calculation: a schema and the inputs of the estimation.
+type Scenario struct {
+	ID    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Notes string `json:"notes,omitempty"`
+
+	Schema        string         `json:"schema"`
+	Rows          int64          `json:"rows"`
+	SizeEstimates map[string]int `json:"size_estimates,omitempty"`
+	// TableRows are the numbers of rows of the tables of a keyspace, Rows is the default
+	TableRows map[string]int64 `json:"table_rows,omitempty"`
+	// Units are the units the sizes are displayed with, the default ones if empty
+	Units string `json:"units,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var (
+	ErrNotFound  = errors.New("scenario not found")
+	ErrEmptyName = errors.New("scenario name is empty")
+)
+
+var scenariosBucket = []byte("scenarios")
+
+// Store persists scenarios in a bbolt database file.
+// It is safe for concurrent use.
+type Store struct {
+	db *bolt.DB
+
+	// now is overridable in tests
+	now func() time.Time
+}
+
+// Open opens or creates the store at path.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open scenarios database %q, err: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(scenariosBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to initialize scenarios database %q, err: %w", path, err)
+	}
+
+	return &Store{
+		db:  db,
+		now: time.Now,
+	}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Check returns an error if the database can't be read.
+func (s *Store) Check() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(scenariosBucket) == nil {
+			return fmt.Errorf("bucket %q not found", scenariosBucket)
+		}
+		return nil
+	})
+}
+
+// List returns all scenarios sorted by name.
+func (s *Store) List() ([]Scenario, error) {
+	var res []Scenario
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(scenariosBucket).ForEach(func(_, value []byte) error {
+			var scenario Scenario
+			if err := json.Unmarshal(value, &scenario); err != nil {
+				return err
+			}
+			res = append(res, scenario)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list scenarios, err: %w", err)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return strings.ToLower(res[i].Name) < strings.ToLower(res[j].Name)
+	})
+
+	return res, nil
+}
+
+// Get returns the scenario identified by id or ErrNotFound.
+func (s *Store) Get(id uint64) (res Scenario, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		res, err = get(tx, id)
+		return err
+	})
+	return
+}
+
+// Save creates the scenario if its ID is zero, otherwise it replaces the existing scenario.
+// It returns the scenario as stored.
+func (s *Store) Save(scenario Scenario) (res Scenario, err error) {
+	if strings.TrimSpace(scenario.Name) == "" {
+		return res, ErrEmptyName
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		now := s.now().UTC()
+
+		if scenario.ID == 0 {
+			id, err := tx.Bucket(scenariosBucket).NextSequence()
+			if err != nil {
+				return err
+			}
+
+			scenario.ID = id
+			scenario.CreatedAt = now
+		} else {
+			existing, err := get(tx, scenario.ID)
+			if err != nil {
+				return err
+			}
+
+			scenario.CreatedAt = existing.CreatedAt
+		}
+		scenario.UpdatedAt = now
+
+		res = scenario
+
+		return put(tx, scenario)
+	})
+	return
+}
+
+// Clone creates a copy of the scenario identified by id with a new name.
+func (s *Store) Clone(id uint64, name string) (Scenario, error) {
+	scenario, err := s.Get(id)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	if name == "" {
+		name = "Copy of " + scenario.Name
+	}
+
+	scenario.ID = 0
+	scenario.Name = name
+
+	return s.Save(scenario)
+}
+
+// Delete removes the scenario identified by id or returns ErrNotFound.
+func (s *Store) Delete(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if _, err := get(tx, id); err != nil {
+			return err
+		}
+		return tx.Bucket(scenariosBucket).Delete(key(id))
+	})
+}
+
+func key(id uint64) []byte {
+	var res [8]byte
+	binary.BigEndian.PutUint64(res[:], id)
+	return res[:]
+}
+
+func get(tx *bolt.Tx, id uint64) (res Scenario, err error) {
+	data := tx.Bucket(scenariosBucket).Get(key(id))
+	if data == nil {
+		return res, ErrNotFound
+	}
+
+	err = json.Unmarshal(data, &res)
+	return
+}
+
+func put(tx *bolt.Tx, scenario Scenario) error {
+	data, err := json.Marshal(scenario)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(scenariosBucket).Put(key(scenario.ID), data)
+}
@@ -0,0 +1,123 @@
+package scenario
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func openTestStore(t *testing.T) *Store {
+	store, err := Open(filepath.Join(t.TempDir(), "scenarios.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+
+	return store
+}
+
+func TestStore(t *testing.T) {
+	store := openTestStore(t)
+
+	// Create
+
+	events, err := store.Save(Scenario{
+		Name:          "events",
+		Notes:         "one partition per user",
+		Schema:        "CREATE TABLE events(user_id uuid, event_id timeuuid, data blob, PRIMARY KEY (user_id, event_id));",
+		Rows:          1000,
+		SizeEstimates: map[string]int{"data": 200},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), events.ID)
+	require.Equal(t, events.CreatedAt, events.UpdatedAt)
+
+	accounts, err := store.Save(Scenario{Name: "Accounts", Schema: "CREATE TABLE accounts(id uuid PRIMARY KEY);", Rows: 1})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), accounts.ID)
+
+	// Update
+
+	events.Rows = 2000
+	updated, err := store.Save(events)
+	require.NoError(t, err)
+	require.Equal(t, events.CreatedAt, updated.CreatedAt)
+	require.True(t, updated.UpdatedAt.After(events.UpdatedAt))
+
+	got, err := store.Get(events.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated, got)
+
+	// Clone
+
+	clone, err := store.Clone(events.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), clone.ID)
+	require.Equal(t, "Copy of events", clone.Name)
+	require.Equal(t, updated.Schema, clone.Schema)
+	require.Equal(t, updated.SizeEstimates, clone.SizeEstimates)
+
+	// List is sorted by name
+
+	scenarios, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, scenarios, 3)
+	require.Equal(t, "Accounts", scenarios[0].Name)
+	require.Equal(t, "Copy of events", scenarios[1].Name)
+	require.Equal(t, "events", scenarios[2].Name)
+
+	// Delete
+
+	require.NoError(t, store.Delete(events.ID))
+	_, err = store.Get(events.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, store.Delete(events.ID), ErrNotFound)
+}
+
+func TestStoreErrors(t *testing.T) {
+	store := openTestStore(t)
+
+	_, err := store.Save(Scenario{Name: " "})
+	require.ErrorIs(t, err, ErrEmptyName)
+
+	_, err = store.Save(Scenario{ID: 10, Name: "foo"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = store.Clone(10, "bar")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStoreCheck(t *testing.T) {
+	store := openTestStore(t)
+	require.NoError(t, store.Check())
+
+	require.NoError(t, store.Close())
+	require.Error(t, store.Check())
+}
+
+func TestStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenarios.db")
+
+	store, err := Open(path)
+	require.NoError(t, err)
+
+	saved, err := store.Save(Scenario{Name: "events", Rows: 10})
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	store, err = Open(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	got, err := store.Get(saved.ID)
+	require.NoError(t, err)
+	require.Equal(t, saved, got)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+	"rischmann.fr/cassandra-partition-calculator/scenario"
+	"rischmann.fr/cassandra-partition-calculator/ui"
+	"rischmann.fr/cassandra-partition-calculator/ui/fragments"
+)
+
+// scenarioFormData returns the saved scenario fields of the evaluate form.
+func scenarioFormData(form url.Values) ui.ScenarioFormData {
+	id, _ := strconv.ParseUint(form.Get("scenario_id"), 10, 64)
+
+	return ui.ScenarioFormData{
+		ID:    id,
+		Name:  form.Get("scenario_name"),
+		Notes: form.Get("scenario_notes"),
+	}
+}
+
+// scenarioForm returns the evaluate form restoring the scenario.
+func scenarioForm(s scenario.Scenario) url.Values {
+	state := permalinkState{
+		Schema:        s.Schema,
+		Rows:          strconv.FormatInt(s.Rows, 10),
+		SizeEstimates: s.SizeEstimates,
+		TableRows:     s.TableRows,
+		Units:         s.Units,
+	}
+	return state.Form()
+}
+
+func (c *serveCommandConfig) scenarioURL(ctx context.Context, id uint64) string {
+	return fmt.Sprintf("%s/scenarios/%d", c.baseURLOf(ctx), id)
+}
+
+// allowMethod returns true if the request method is method, otherwise it replies with a 405.
+func allowMethod(w http.ResponseWriter, req *http.Request, method string) bool {
+	if req.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+	return false
+}
+
+func (c *serveCommandConfig) scenariosHandler(w http.ResponseWriter, req *http.Request) {
+	if c.scenarios == nil {
+		http.NotFound(w, req)
+		return
+	}
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+
+	scenarios, err := c.scenarios.List()
+	if err != nil {
+		c.logger(req.Context()).Error("unable to list scenarios", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	baseURL := c.baseURLOf(req.Context())
+	page := ui.MainPage(baseURL, pageTitle, c.languageLinks(req), ui.ScenariosComponent(baseURL, scenarios))
+	page.Render(req.Context(), w)
+}
+
+// scenarioHandler serves the actions on a single scenario:
+// * GET /scenarios/<id> opens the scenario
+// * POST /scenarios/<id>/clone clones the scenario
+// * POST /scenarios/<id>/delete deletes the scenario
+// * POST /scenarios/save creates or updates the scenario from the evaluate form
+func (c *serveCommandConfig) scenarioHandler(w http.ResponseWriter, req *http.Request) {
+	if c.scenarios == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/scenarios/")
+	if path == "save" {
+		c.saveScenarioHandler(w, req)
+		return
+	}
+
+	idStr, action, _ := strings.Cut(path, "/")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	switch action {
+	case "":
+		if allowMethod(w, req, http.MethodGet) {
+			c.openScenario(w, req, id)
+		}
+
+	case "clone":
+		if !allowMethod(w, req, http.MethodPost) {
+			return
+		}
+
+		clone, err := c.scenarios.Clone(id, "")
+		if err != nil {
+			c.scenarioError(w, req, err)
+			return
+		}
+
+		http.Redirect(w, req, c.scenarioURL(req.Context(), clone.ID), http.StatusSeeOther)
+
+	case "delete":
+		if !allowMethod(w, req, http.MethodPost) {
+			return
+		}
+
+		if err := c.scenarios.Delete(id); err != nil {
+			c.scenarioError(w, req, err)
+			return
+		}
+
+		http.Redirect(w, req, c.baseURLOf(req.Context())+"/scenarios", http.StatusSeeOther)
+
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (c *serveCommandConfig) scenarioError(w http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, scenario.ErrNotFound) {
+		http.NotFound(w, req)
+		return
+	}
+
+	c.logger(req.Context()).Error("unable to access scenario", zap.Error(err))
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (c *serveCommandConfig) openScenario(w http.ResponseWriter, req *http.Request, id uint64) {
+	s, err := c.scenarios.Get(id)
+	if err != nil {
+		c.scenarioError(w, req, err)
+		return
+	}
+
+	c.renderPage(w, req, http.StatusOK, ui.FormData{
+		Schema:  s.Schema,
+		Rows:    strconv.FormatInt(s.Rows, 10),
+		Units:   s.Units,
+		Results: c.evaluate(req.Context(), scenarioForm(s)),
+		Scenario: ui.ScenarioFormData{
+			ID:    s.ID,
+			Name:  s.Name,
+			Notes: s.Notes,
+		},
+	})
+}
+
+func (c *serveCommandConfig) saveScenarioHandler(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodPost) {
+		return
+	}
+
+	saved, err := c.saveScenario(req)
+	if err != nil {
+		c.logger(req.Context()).Error("unable to save scenario", zap.Error(err))
+
+		data := fragments.ResultsData{
+			ErrorMessages: errorMessages(req.Context(), err),
+			SchemaErrors:  schemaErrors(req.Context(), err),
+		}
+
+		if isHTMXRequest(req) {
+			component := fragments.Results(data)
+			component.Render(req.Context(), w)
+			return
+		}
+
+		c.renderPage(w, req, http.StatusOK, ui.FormData{
+			Schema:   req.Form.Get("schema"),
+			Rows:     req.Form.Get("rows"),
+			Units:    req.Form.Get(unitsParam),
+			Results:  data,
+			Scenario: scenarioFormData(req.Form),
+		})
+		return
+	}
+
+	// htmx can't follow a redirect with a full page load by itself
+	if isHTMXRequest(req) {
+		w.Header().Set("HX-Redirect", c.scenarioURL(req.Context(), saved.ID))
+		return
+	}
+
+	http.Redirect(w, req, c.scenarioURL(req.Context(), saved.ID), http.StatusSeeOther)
+}
+
+func (c *serveCommandConfig) saveScenario(req *http.Request) (scenario.Scenario, error) {
+	if err := req.ParseForm(); err != nil {
+		if isBodyTooLarge(err) {
+			return scenario.Scenario{}, c.formTooLargeError(req.Context())
+		}
+		return scenario.Scenario{}, i18n.NewError("unable to parse form, err: %s", err)
+	}
+	form := scenarioFormData(req.Form)
+
+	if strings.TrimSpace(form.Name) == "" {
+		return scenario.Scenario{}, &validationError{
+			field: "scenario_name",
+			err:   errFieldEmpty,
+		}
+	}
+
+	// Only valid calculations can be saved
+	res, err := c.parseEvaluateForm(req.Form)
+	if err == nil && c.isKeyspaceForm(req.Form) {
+		_, err = c.parseKeyspaceForm(req.Form)
+	}
+	if err != nil {
+		return scenario.Scenario{}, err
+	}
+
+	return c.scenarios.Save(scenario.Scenario{
+		ID:            form.ID,
+		Name:          strings.TrimSpace(form.Name),
+		Notes:         form.Notes,
+		Schema:        req.Form.Get("schema"),
+		Rows:          res.rows,
+		SizeEstimates: sizeEstimatesFromForm(req.Form),
+		TableRows:     tableRowsFromForm(req.Form),
+		Units:         req.Form.Get(unitsParam),
+	})
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"rischmann.fr/cassandra-partition-calculator/scenario"
+)
+
+func newTestScenariosServeCommandConfig(t *testing.T) *serveCommandConfig {
+	c := newTestServeCommandConfig(t)
+
+	store, err := scenario.Open(filepath.Join(t.TempDir(), "scenarios.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+	c.scenarios = store
+
+	return c
+}
+
+func doRequest(t testing.TB, handler http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestScenariosUI(t *testing.T) {
+	c := newTestScenariosServeCommandConfig(t)
+	handler := c.routes()
+
+	// The form has the scenario fields
+
+	rec := doRequest(t, handler, http.MethodGet, "/", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `name="scenario_name"`)
+
+	// Save
+
+	form := url.Values{
+		"schema":           {"CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));"},
+		"rows":             {"1000"},
+		"size::event_data": {"100"},
+		"units":            {"si"},
+		"scenario_name":    {"events by user"},
+		"scenario_notes":   {"one partition per user"},
+	}
+
+	rec = doRequest(t, handler, http.MethodPost, "/scenarios/save", "application/x-www-form-urlencoded", form.Encode())
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Equal(t, "/scenarios/1", rec.Header().Get("Location"))
+
+	// Open
+
+	rec = doRequest(t, handler, http.MethodGet, "/scenarios/1", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, `name="scenario_id" value="1"`)
+	require.Contains(t, body, `value="events by user"`)
+	require.Contains(t, body, "one partition per user")
+	require.Contains(t, body, `name="size::event_data" value="100"`)
+	require.Contains(t, body, "Partition size")
+	// With the units it was saved with
+	require.Contains(t, body, `<option value="si" selected>`)
+	require.Contains(t, body, "132,032 bytes (132 kB)")
+
+	// Update
+
+	form.Set("scenario_id", "1")
+	form.Set("rows", "2000")
+
+	req := httptest.NewRequest(http.MethodPost, "/scenarios/save", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "/scenarios/1", rec.Header().Get("HX-Redirect"))
+
+	saved, err := c.scenarios.Get(1)
+	require.NoError(t, err)
+	require.Equal(t, int64(2000), saved.Rows)
+	require.Equal(t, "si", saved.Units)
+
+	// Clone and list
+
+	rec = doRequest(t, handler, http.MethodPost, "/scenarios/1/clone", "", "")
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Equal(t, "/scenarios/2", rec.Header().Get("Location"))
+
+	rec = doRequest(t, handler, http.MethodGet, "/scenarios", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "Copy of events by user")
+
+	// Delete
+
+	rec = doRequest(t, handler, http.MethodPost, "/scenarios/1/delete", "", "")
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+
+	rec = doRequest(t, handler, http.MethodGet, "/scenarios/1", "", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestScenariosUIErrors(t *testing.T) {
+	c := newTestScenariosServeCommandConfig(t)
+	handler := c.routes()
+
+	form := url.Values{
+		"schema": {"CREATE TABLE events(user_id uuid PRIMARY KEY);"},
+		"rows":   {"10"},
+	}
+
+	// Name is mandatory
+
+	rec := doRequest(t, handler, http.MethodPost, "/scenarios/save", "application/x-www-form-urlencoded", form.Encode())
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.Contains(t, rec.Body.String(), `class="error-message"`)
+
+	// Invalid calculations can't be saved
+
+	form.Set("scenario_name", "events")
+	form.Set("schema", "CREATE TABLE")
+
+	rec = doRequest(t, handler, http.MethodPost, "/scenarios/save", "application/x-www-form-urlencoded", form.Encode())
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	scenarios, err := c.scenarios.List()
+	require.NoError(t, err)
+	require.Empty(t, scenarios)
+
+	rec = doRequest(t, handler, http.MethodGet, "/scenarios/save", "", "")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestScenariosDisabled(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+	handler := c.routes()
+
+	rec := doRequest(t, handler, http.MethodGet, "/", "", "")
+	require.NotContains(t, rec.Body.String(), `name="scenario_name"`)
+
+	rec = doRequest(t, handler, http.MethodGet, "/scenarios", "", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(t, handler, http.MethodGet, "/api/v1/scenarios", "", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestScenariosAPI(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	c := newTestScenariosServeCommandConfig(t)
+	handler := c.routes()
+
+	testCases := []struct {
+		method string
+		target string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/api/v1/scenarios", "/api/v1/scenarios", "", http.StatusOK},
+		{
+			http.MethodPost, "/api/v1/scenarios", "/api/v1/scenarios",
+			`{"name": "events", "schema": "CREATE TABLE events(user_id uuid, event_id timeuuid, event_data blob, PRIMARY KEY (user_id, event_id));", "rows": 1000, "size_estimates": {"event_data": 100}}`,
+			http.StatusCreated,
+		},
+		{http.MethodPost, "/api/v1/scenarios", "/api/v1/scenarios", `{"name": "", "schema": "CREATE TABLE events(id int PRIMARY KEY);", "rows": 10}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/scenarios", "/api/v1/scenarios", `{"name": "foo", "schema": "CREATE TABLE", "rows": 10}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/api/v1/scenarios/1", "/api/v1/scenarios/{id}", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/scenarios/10", "/api/v1/scenarios/{id}", "", http.StatusNotFound},
+		{http.MethodPut, "/api/v1/scenarios/1", "/api/v1/scenarios/{id}", `{"name": "events v2", "schema": "CREATE TABLE events(user_id uuid PRIMARY KEY);", "rows": 1}`, http.StatusOK},
+		{http.MethodPost, "/api/v1/scenarios/1/clone", "/api/v1/scenarios/{id}/clone", `{"name": "events v3"}`, http.StatusCreated},
+		{http.MethodGet, "/api/v1/scenarios", "/api/v1/scenarios", "", http.StatusOK},
+		{http.MethodDelete, "/api/v1/scenarios/1", "/api/v1/scenarios/{id}", "", http.StatusNoContent},
+		{http.MethodDelete, "/api/v1/scenarios/1", "/api/v1/scenarios/{id}", "", http.StatusNotFound},
+		{http.MethodPatch, "/api/v1/scenarios/2", "/api/v1/scenarios/{id}", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		rec := doRequest(t, handler, tc.method, tc.target, "application/json", tc.body)
+		require.Equal(t, tc.status, rec.Code, "%s %s: %s", tc.method, tc.target, rec.Body.String())
+
+		if tc.status == http.StatusNoContent {
+			require.Empty(t, rec.Body.Bytes())
+			continue
+		}
+
+		// Undocumented methods are documented on the GET or POST operation
+		method := tc.method
+		if _, ok := spec.Paths[tc.path][strings.ToLower(method)]; !ok {
+			method = http.MethodGet
+		}
+
+		schema := spec.responseSchema(t, tc.path, method, rec.Code)
+		requireMatchesSchema(t, spec, schema, rec.Body.Bytes())
+	}
+
+	// Check the final state
+
+	rec := doRequest(t, handler, http.MethodGet, "/api/v1/scenarios", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response apiScenariosResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Scenarios, 1)
+	require.Equal(t, uint64(2), response.Scenarios[0].ID)
+	require.Equal(t, "events v3", response.Scenarios[0].Name)
+	require.Equal(t, int64(1), response.Scenarios[0].Rows)
+}
+
+func TestScenariosAPITableRows(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	c := newTestScenariosServeCommandConfig(t)
+	handler := c.routes()
+
+	requestSchema := spec.Paths["/api/v1/scenarios"]["post"].RequestBody.Content["application/json"].Schema
+
+	body := `{"name": "keyspace", "schema": ` + strconv.Quote(testKeyspaceSchema) + `, "rows": 100, "size_estimates": {"ks.events.data": 50}, "table_rows": {"ks.events": 1000}}`
+
+	var requestBody interface{}
+	require.NoError(t, json.Unmarshal([]byte(body), &requestBody))
+	require.Empty(t, spec.validate(t, "$", requestSchema, requestBody))
+
+	rec := doRequest(t, handler, http.MethodPost, "/api/v1/scenarios", "application/json", body)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	requireMatchesSchema(t, spec, spec.responseSchema(t, "/api/v1/scenarios", http.MethodPost, rec.Code), rec.Body.Bytes())
+
+	// GET returns the numbers of rows of the tables
+	rec = doRequest(t, handler, http.MethodGet, "/api/v1/scenarios/1", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	requireMatchesSchema(t, spec, spec.responseSchema(t, "/api/v1/scenarios/{id}", http.MethodGet, rec.Code), rec.Body.Bytes())
+
+	var got apiScenario
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Equal(t, map[string]int64{"ks.events": 1000}, got.TableRows)
+	require.Equal(t, map[string]int{"ks.events.data": 50}, got.SizeEstimates)
+
+	// PUT of what GET returned keeps them
+	rec = doRequest(t, handler, http.MethodPut, "/api/v1/scenarios/1", "application/json", `{"name": "keyspace v2", "schema": `+strconv.Quote(got.Schema)+`, "rows": 100, "size_estimates": {"ks.events.data": 50}, "table_rows": {"ks.events": 2000}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	saved, err := c.scenarios.Get(1)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"ks.events": 2000}, saved.TableRows)
+
+	// Negative numbers of rows are rejected
+	rec = doRequest(t, handler, http.MethodPut, "/api/v1/scenarios/1", "application/json", `{"name": "keyspace v3", "schema": `+strconv.Quote(got.Schema)+`, "rows": 100, "table_rows": {"ks.events": -5}}`)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	requireMatchesSchema(t, spec, spec.responseSchema(t, "/api/v1/scenarios/{id}", http.MethodPut, rec.Code), rec.Body.Bytes())
+	require.Contains(t, rec.Body.String(), `"field": "table_rows.ks.events"`)
+}
+
+func TestScenariosAPIUnits(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	c := newTestScenariosServeCommandConfig(t)
+	handler := c.routes()
+
+	rec := doRequest(t, handler, http.MethodPost, "/api/v1/scenarios", "application/json", `{"name": "events", "schema": "CREATE TABLE events(user_id uuid PRIMARY KEY);", "rows": 10, "units": "MiB"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	requireMatchesSchema(t, spec, spec.responseSchema(t, "/api/v1/scenarios", http.MethodPost, rec.Code), rec.Body.Bytes())
+
+	var got apiScenario
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Equal(t, "MiB", got.Units)
+
+	rec = doRequest(t, handler, http.MethodPut, "/api/v1/scenarios/1", "application/json", `{"name": "events", "schema": "CREATE TABLE events(user_id uuid PRIMARY KEY);", "rows": 10, "units": "furlong"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.Contains(t, rec.Body.String(), `"field": "units"`)
+}
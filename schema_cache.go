@@ -0,0 +1,100 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+// schemaCache caches the result of parsing schemas, keyed by the hash of the schema.
+//
+// The live evaluation of the form sends the same schema over and over while the size estimates change,
+// there's no need to parse it every time.
+//
+// A nil cache is valid and parses the schema every time.
+type schemaCache struct {
+	mu      sync.Mutex
+	maxSize int
+	entries map[[sha256.Size]byte]*list.Element
+	lru     *list.List
+}
+
+type schemaCacheEntry struct {
+	key    [sha256.Size]byte
+	tables []cql.Schema
+	types  []cql.UserType
+	err    error
+}
+
+func newSchemaCache(maxSize int) *schemaCache {
+	return &schemaCache{
+		maxSize: maxSize,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// parseDescribe is a cached cql.ParseDescribe.
+// The tables returned can be modified without affecting the cache.
+func (c *schemaCache) parseDescribe(data string) ([]cql.Schema, []cql.UserType, error) {
+	if c == nil {
+		return cql.ParseDescribe(data)
+	}
+
+	key := sha256.Sum256([]byte(data))
+
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+
+	var entry *schemaCacheEntry
+	if ok {
+		entry = elem.Value.(*schemaCacheEntry)
+	} else {
+		tables, types, err := cql.ParseDescribe(data)
+		entry = &schemaCacheEntry{
+			key:    key,
+			tables: tables,
+			types:  types,
+			err:    err,
+		}
+		c.add(entry)
+	}
+
+	tables := make([]cql.Schema, len(entry.tables))
+	for i, table := range entry.tables {
+		tables[i] = table.Clone()
+	}
+
+	return tables, entry.types, entry.err
+}
+
+func (c *schemaCache) add(entry *schemaCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another request may have parsed the same schema concurrently
+	if _, ok := c.entries[entry.key]; ok {
+		return
+	}
+
+	c.entries[entry.key] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.maxSize {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*schemaCacheEntry).key)
+	}
+}
+
+func (c *schemaCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCache(t *testing.T) {
+	cache := newSchemaCache(2)
+
+	const schema = "CREATE TABLE events(user_id uuid PRIMARY KEY, name text);"
+
+	tables, _, err := cache.parseDescribe(schema)
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	require.Equal(t, 1, cache.len())
+
+	// Modifying the result doesn't modify the cache
+	tables[0] = tables[0].WithColumnSizeEstimate("name", 100)
+
+	tables, _, err = cache.parseDescribe(schema)
+	require.NoError(t, err)
+	require.Equal(t, 0, tables[0].Columns[1].Size())
+	require.Equal(t, 1, cache.len())
+
+	// Errors are cached too
+	_, _, err = cache.parseDescribe("CREATE TABLE")
+	require.Error(t, err)
+	require.Equal(t, 2, cache.len())
+
+	// The least recently used entry is evicted
+	_, _, err = cache.parseDescribe("CREATE TABLE users(id uuid PRIMARY KEY);")
+	require.NoError(t, err)
+	require.Equal(t, 2, cache.len())
+
+	_, ok := cache.entries[sha256.Sum256([]byte(schema))]
+	require.False(t, ok)
+}
+
+func TestSchemaCacheNil(t *testing.T) {
+	var cache *schemaCache
+
+	tables, _, err := cache.parseDescribe("CREATE TABLE events(user_id uuid PRIMARY KEY);")
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+}
+
+func TestSchemaCacheConcurrent(t *testing.T) {
+	cache := newSchemaCache(4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			schema := fmt.Sprintf("CREATE TABLE events%d(user_id uuid PRIMARY KEY, name text);", i%8)
+			for j := 0; j < 50; j++ {
+				tables, _, err := cache.parseDescribe(schema)
+				require.NoError(t, err)
+				_ = tables[0].WithColumnSizeEstimate("name", j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, cache.len(), 4)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/vrischmann/hutil/v3"
+	"go.uber.org/zap"
+
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+)
+
+// serverLimits are the timeouts and limits of the HTTP server.
+type serverLimits struct {
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	shutdownTimeout   time.Duration
+	// maxBodySize is the maximum size of a request body in bytes
+	maxBodySize int64
+}
+
+func defaultServerLimits() serverLimits {
+	return serverLimits{
+		readHeaderTimeout: 5 * time.Second,
+		readTimeout:       10 * time.Second,
+		writeTimeout:      30 * time.Second,
+		idleTimeout:       2 * time.Minute,
+		shutdownTimeout:   30 * time.Second,
+		maxBodySize:       1 << 20,
+	}
+}
+
+// newMaxBodySizeMiddleware limits the size of the request bodies.
+// Reading more than limit bytes fails with a *http.MaxBytesError.
+func newMaxBodySizeMiddleware(limit int64) hutil.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			req.Body = http.MaxBytesReader(w, req.Body, limit)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// isBodyTooLarge returns true if err is caused by a request body larger than the limit.
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// formTooLargeError is the error shown when a form is larger than the body size limit.
+// The form can't be read so the limit is formatted with the default units.
+func (c *serveCommandConfig) formTooLargeError(ctx context.Context) error {
+	limit := newFormatter(ctx, format.IEC).Bytes(c.limits.maxBodySize)
+	return i18n.NewError("the form is larger than the limit of %s", limit)
+}
+
+// serve serves handler on listener until ctx is done, with TLS if a certificate is configured.
+// The server is then shut down gracefully: it stops accepting connections and waits for the requests in flight.
+func (c *serveCommandConfig) serve(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: c.limits.readHeaderTimeout,
+		ReadTimeout:       c.limits.readTimeout,
+		WriteTimeout:      c.limits.writeTimeout,
+		IdleTimeout:       c.limits.idleTimeout,
+		ErrorLog:          zap.NewStdLog(c.root.logger),
+	}
+
+	if c.certificates != nil {
+		server.TLSConfig = c.certificates.tlsConfig()
+
+		if c.tlsReloadInterval > 0 {
+			watchCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			go c.certificates.watch(watchCtx, c.tlsReloadInterval)
+		}
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if c.certificates != nil {
+			errCh <- server.ServeTLS(listener, "", "")
+		} else {
+			errCh <- server.Serve(listener)
+		}
+	}()
+	c.ready.Store(true)
+
+	select {
+	case err := <-errCh:
+		c.ready.Store(false)
+		return err
+
+	case <-ctx.Done():
+	}
+
+	// Report not ready first so that no new requests are routed to this instance
+	c.ready.Store(false)
+
+	c.root.logger.Info("shutting down", zap.Duration("timeout", c.limits.shutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.limits.shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	c.root.logger.Info("shut down")
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vrischmann/hutil/v3"
+
+	"rischmann.fr/cassandra-partition-calculator/cassandra"
+	"rischmann.fr/cassandra-partition-calculator/cql"
+)
+
+// serverMetrics are the metrics exposed by the serve command on /metrics.
+// A nil *serverMetrics records nothing.
+//
+// Every server has its own registry so that several can run in the same process, like in the tests.
+type serverMetrics struct {
+	registry *prometheus.Registry
+
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	parseFailures    prometheus.Counter
+	estimateDuration prometheus.Histogram
+}
+
+func newServerMetrics() *serverMetrics {
+	res := &serverMetrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "partition_calculator_http_requests_total",
+			Help: "Number of HTTP requests handled, by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "partition_calculator_http_request_duration_seconds",
+			Help:    "Duration of the HTTP requests, by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		parseFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "partition_calculator_schema_parse_failures_total",
+			Help: "Number of schemas which could not be parsed or are invalid.",
+		}),
+		estimateDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "partition_calculator_estimate_duration_seconds",
+			Help:    "Duration of the estimations of the partition size.",
+			Buckets: []float64{0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01},
+		}),
+	}
+
+	res.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		res.requests,
+		res.requestDuration,
+		res.parseFailures,
+		res.estimateDuration,
+	)
+
+	return res
+}
+
+// handler serves the metrics in the Prometheus exposition format.
+func (m *serverMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// metricsMethod limits the values of the method label to the standard methods.
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// statusRecorder records the status code and the size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	size       int
+}
+
+func (w *statusRecorder) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+// Flush flushes the wrapped writer if it can be, so that the recorder doesn't prevent streaming responses.
+func (w *statusRecorder) Flush() {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// middleware records the requests per route, the route being the pattern of routes matching the request.
+func (m *serverMetrics) middleware(routes *http.ServeMux) hutil.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, route := routes.Handler(req)
+			if route == "" {
+				route = "unknown"
+			}
+			method := metricsMethod(req.Method)
+
+			rec := &statusRecorder{ResponseWriter: w}
+
+			start := time.Now()
+			next.ServeHTTP(rec, req)
+			elapsed := time.Since(start)
+
+			if rec.statusCode == 0 {
+				rec.statusCode = http.StatusOK
+			}
+
+			m.requests.WithLabelValues(route, method, strconv.Itoa(rec.statusCode)).Inc()
+			m.requestDuration.WithLabelValues(route, method).Observe(elapsed.Seconds())
+		})
+	}
+}
+
+func (m *serverMetrics) schemaParseFailed() {
+	if m == nil {
+		return
+	}
+	m.parseFailures.Inc()
+}
+
+// estimate estimates the partition size of schema and records the duration of the estimation.
+func (m *serverMetrics) estimate(schema cql.Schema, rows int64) (cassandra.Estimation, error) {
+	start := time.Now()
+	res, err := cassandra.Estimate(schema, rows)
+
+	if m != nil {
+		m.estimateDuration.Observe(time.Since(start).Seconds())
+	}
+
+	return res, err
+}
+
+//
+// Health checks
+//
+
+// healthzHandler reports that the process is alive.
+func (c *serveCommandConfig) healthzHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// readyzHandler reports if the server can handle requests: it is listening and the scenarios database is usable.
+func (c *serveCommandConfig) readyzHandler(w http.ResponseWriter, req *http.Request) {
+	if !c.ready.Load() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	if c.scenarios != nil {
+		if err := c.scenarios.Check(); err != nil {
+			http.Error(w, "scenarios database unavailable", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok\n"))
+}
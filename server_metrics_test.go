@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+
+	routes := c.routes()
+	handler := c.metrics.middleware(routes)(routes)
+
+	form := url.Values{
+		"schema": {"CREATE TABLE events(user_id uuid PRIMARY KEY, name text);"},
+		"rows":   {"10"},
+	}
+	rec := doRequest(t, handler, http.MethodPost, "/evaluate", "application/x-www-form-urlencoded", form.Encode())
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	form.Set("schema", "CREATE TABLE events(")
+	rec = doRequest(t, handler, http.MethodPost, "/evaluate", "application/x-www-form-urlencoded", form.Encode())
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, handler, http.MethodGet, "/scenarios/foo", "", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(c.metrics.requests.WithLabelValues("/evaluate", "POST", "200")))
+	require.Equal(t, float64(1), testutil.ToFloat64(c.metrics.requests.WithLabelValues("/evaluate", "POST", "422")))
+	require.Equal(t, float64(1), testutil.ToFloat64(c.metrics.requests.WithLabelValues("/scenarios/", "GET", "404")))
+	require.Equal(t, float64(1), testutil.ToFloat64(c.metrics.parseFailures))
+
+	rec = doRequest(t, handler, http.MethodGet, "/metrics", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, `partition_calculator_http_requests_total{code="422",method="POST",route="/evaluate"} 1`)
+	require.Contains(t, body, `partition_calculator_http_request_duration_seconds_count{method="POST",route="/evaluate"} 2`)
+	require.Contains(t, body, "partition_calculator_schema_parse_failures_total 1\n")
+	require.Contains(t, body, "partition_calculator_estimate_duration_seconds_count 1\n")
+	require.Contains(t, body, "go_goroutines ")
+}
+
+func TestStatusRecorderFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &statusRecorder{ResponseWriter: rec}
+
+	// The recorder is a flusher so that the responses can be streamed
+	var writer http.ResponseWriter = w
+	flusher, ok := writer.(http.Flusher)
+	require.True(t, ok)
+
+	flusher.Flush()
+	require.True(t, rec.Flushed)
+	require.Equal(t, http.StatusOK, w.statusCode)
+
+	require.NoError(t, http.NewResponseController(w).Flush())
+}
+
+func TestHealthChecks(t *testing.T) {
+	c := newTestScenariosServeCommandConfig(t)
+	handler := c.routes()
+
+	rec := doRequest(t, handler, http.MethodGet, "/healthz", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "ok\n", rec.Body.String())
+
+	// Not listening yet
+	rec = doRequest(t, handler, http.MethodGet, "/readyz", "", "")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	c.ready.Store(true)
+
+	rec = doRequest(t, handler, http.MethodGet, "/readyz", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// The scenarios database is unusable once closed
+	require.NoError(t, c.scenarios.Close())
+
+	rec = doRequest(t, handler, http.MethodGet, "/readyz", "", "")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.True(t, strings.HasPrefix(rec.Body.String(), "scenarios database unavailable"))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+	c.limits.maxBodySize = 64
+
+	handler := newMaxBodySizeMiddleware(c.limits.maxBodySize)(c.routes())
+
+	form := url.Values{
+		"schema": {"CREATE TABLE events(user_id uuid PRIMARY KEY, name text, data blob, created_at timestamp);"},
+		"rows":   {"10"},
+	}
+
+	rec := doRequest(t, handler, http.MethodPost, "/evaluate", "application/x-www-form-urlencoded", form.Encode())
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	require.Contains(t, rec.Body.String(), "the form is larger than the limit of 64 B")
+
+	rec = doRequest(t, handler, http.MethodPost, "/api/v1/estimate", "application/json", `{"schema": "`+form.Get("schema")+`", "rows": 10}`)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	require.Contains(t, rec.Body.String(), `"code": "request_too_large"`)
+
+	// Small enough
+	rec = doRequest(t, handler, http.MethodPost, "/api/v1/estimate", "application/json", `{"schema": "CREATE TABLE t(id int PRIMARY KEY)", "rows": 1}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServeGracefulShutdown(t *testing.T) {
+	c := newTestServeCommandConfig(t)
+	c.limits.shutdownTimeout = 5 * time.Second
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		_, _ = io.WriteString(w, "done")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- c.serve(ctx, listener, handler)
+	}()
+
+	// Start a request and shut down while it's in flight
+
+	type response struct {
+		body string
+		err  error
+	}
+	responseCh := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/")
+		if err != nil {
+			responseCh <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		responseCh <- response{body: string(body), err: err}
+	}()
+
+	<-started
+	require.True(t, c.ready.Load())
+
+	cancel()
+
+	// New connections are refused while draining
+	require.Eventually(t, func() bool {
+		conn, err := net.DialTimeout("tcp", listener.Addr().String(), time.Second)
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		return false
+	}, time.Second, 10*time.Millisecond)
+	require.False(t, c.ready.Load())
+
+	close(release)
+
+	resp := <-responseCh
+	require.NoError(t, resp.err)
+	require.Equal(t, "done", strings.TrimSpace(resp.body))
+
+	require.NoError(t, <-serveErr)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// certificateReloader serves a TLS certificate and reloads it when its files change,
+// so that a renewed certificate is used without restarting the server.
+type certificateReloader struct {
+	logger   *zap.Logger
+	certPath string
+	keyPath  string
+
+	certificate atomic.Pointer[tls.Certificate]
+	// modTimes are the modification times of the files of the current certificate
+	modTimes [2]time.Time
+}
+
+func newCertificateReloader(logger *zap.Logger, certPath, keyPath string) (*certificateReloader, error) {
+	res := &certificateReloader{
+		logger:   logger,
+		certPath: certPath,
+		keyPath:  keyPath,
+	}
+
+	if _, err := res.reload(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *certificateReloader) filesModTimes() ([2]time.Time, error) {
+	var res [2]time.Time
+	for i, path := range []string{r.certPath, r.keyPath} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return res, err
+		}
+		res[i] = fi.ModTime()
+	}
+	return res, nil
+}
+
+// reload loads the certificate if its files changed since the last load.
+// It returns true if the certificate was loaded.
+func (r *certificateReloader) reload() (bool, error) {
+	modTimes, err := r.filesModTimes()
+	if err != nil {
+		return false, fmt.Errorf("unable to stat the TLS certificate, err: %w", err)
+	}
+	if modTimes == r.modTimes {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+	if err != nil {
+		return false, fmt.Errorf("unable to load the TLS certificate, err: %w", err)
+	}
+
+	r.certificate.Store(&certificate)
+	r.modTimes = modTimes
+
+	return true, nil
+}
+
+// watch checks the files of the certificate for changes every interval until ctx is done.
+// The current certificate is kept if the new one can't be loaded, for example while only one of the files is replaced.
+func (r *certificateReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := r.reload()
+		switch {
+		case err != nil:
+			r.logger.Error("unable to reload the TLS certificate", zap.Error(err))
+		case reloaded:
+			r.logger.Info("reloaded the TLS certificate", zap.String("cert", r.certPath))
+		}
+	}
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.certificate.Load(), nil
+}
+
+// tlsConfig is the TLS configuration of the server.
+func (r *certificateReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
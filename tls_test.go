@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 with the common name.
+func writeTestCertificate(t *testing.T, certPath, keyPath, commonName string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+func certificateCommonName(t *testing.T, certificate *tls.Certificate) string {
+	t.Helper()
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	writeTestCertificate(t, certPath, keyPath, "first")
+
+	reloader, err := newCertificateReloader(zap.NewNop(), certPath, keyPath)
+	require.NoError(t, err)
+
+	certificate, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "first", certificateCommonName(t, certificate))
+
+	// Nothing changed
+
+	reloaded, err := reloader.reload()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	// A broken certificate is not used
+
+	require.NoError(t, os.WriteFile(keyPath, []byte("foobar"), 0o600))
+	require.NoError(t, os.Chtimes(keyPath, time.Now(), time.Now().Add(time.Minute)))
+
+	_, err = reloader.reload()
+	require.ErrorContains(t, err, "unable to load the TLS certificate")
+
+	certificate, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "first", certificateCommonName(t, certificate))
+
+	// The renewed certificate replaces the current one
+
+	writeTestCertificate(t, certPath, keyPath, "second")
+	require.NoError(t, os.Chtimes(certPath, time.Now(), time.Now().Add(2*time.Minute)))
+	require.NoError(t, os.Chtimes(keyPath, time.Now(), time.Now().Add(2*time.Minute)))
+
+	reloaded, err = reloader.reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	certificate, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "second", certificateCommonName(t, certificate))
+}
+
+func TestServeTLS(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeTestCertificate(t, certPath, keyPath, "calculator")
+
+	c := newTestServeCommandConfig(t)
+
+	reloader, err := newCertificateReloader(zap.NewNop(), certPath, keyPath)
+	require.NoError(t, err)
+	c.certificates = reloader
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- c.serve(ctx, listener, c.routes())
+	}()
+	defer func() {
+		cancel()
+		require.NoError(t, <-serveErr)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	resp, err := client.Get("https://" + listener.Addr().String() + "/healthz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "ok\n", string(body))
+	require.Equal(t, "calculator", resp.TLS.PeerCertificates[0].Subject.CommonName)
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"context"
+
+	"rischmann.fr/cassandra-partition-calculator/format"
+	"rischmann.fr/cassandra-partition-calculator/i18n"
+)
+
+// unitsParam is the form and query parameter with the units the sizes are displayed with, see format.ParseUnits.
+const unitsParam = "units"
+
+// newFormatter returns a formatter of the numbers in the language of ctx and of the sizes in units.
+func newFormatter(ctx context.Context, units format.Units) format.Formatter {
+	return format.New(i18n.Printer(ctx), units)
+}